@@ -0,0 +1,198 @@
+// Command modpackgraph is the headless counterpart of the ModpackGraph desktop
+// application. It scans a modpack folder and prints or exports its dependency
+// graph without opening a window, so it can run on machines with no display.
+package main
+
+import (
+	"ModpackGraph/internal/app"
+	"ModpackGraph/internal/logging"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const usage = `Usage: modpackgraph <command> [flags] <dir>
+
+Commands:
+  scan    Scan a modpack folder and print its dependency graph
+  export  Scan a modpack folder and write its dependency graph to a file
+  check   Scan a modpack folder and report unmet dependencies
+
+Run "modpackgraph <command> -h" for the flags of a command.
+`
+
+func main() {
+	// Interrupting the command aborts the scan in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		_, _ = fmt.Fprint(stderr, usage)
+		return 2
+	}
+	switch args[0] {
+	case "scan":
+		return runScan(ctx, args[1:], stdout, stderr)
+	case "export":
+		return runExport(ctx, args[1:], stdout, stderr)
+	case "check":
+		return runCheck(ctx, args[1:], stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		_, _ = fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		_, _ = fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+}
+
+func runScan(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "text", "output format: text, json or dot")
+	target := targetFlags(flags)
+	verbose := flags.Bool("verbose", false, "log debug records to stderr as well as to the log file")
+	dir, ok := parseArgs(flags, args)
+	if !ok {
+		return 2
+	}
+	defer startLogging(*verbose, stderr)()
+	graph, err := app.BuildGraph(ctx, app.GraphGenerationOptions{Path: dir, Target: target}, nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "scan failed: %v\n", err)
+		return 1
+	}
+	if err := writeGraph(stdout, graph, *format); err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	format := flags.String("format", "json", "output format: text, json or dot")
+	output := flags.String("output", "", "file to write the graph to (required)")
+	target := targetFlags(flags)
+	verbose := flags.Bool("verbose", false, "log debug records to stderr as well as to the log file")
+	dir, ok := parseArgs(flags, args)
+	if !ok {
+		return 2
+	}
+	defer startLogging(*verbose, stderr)()
+	if *output == "" {
+		_, _ = fmt.Fprintln(stderr, "export: -output is required")
+		return 2
+	}
+	graph, err := app.BuildGraph(ctx, app.GraphGenerationOptions{Path: dir, Target: target}, nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "scan failed: %v\n", err)
+		return 1
+	}
+	f, err := os.Create(*output)
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	err = writeGraph(f, graph, *format)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(stderr, err)
+		return 1
+	}
+	_, _ = fmt.Fprintf(stdout, "Wrote %d mods and %d dependencies to %s\n", len(graph.Nodes), len(graph.Edges), *output)
+	return 0
+}
+
+func runCheck(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	optional := flags.Bool("optional", false, "also fail on missing optional dependencies")
+	target := targetFlags(flags)
+	verbose := flags.Bool("verbose", false, "log debug records to stderr as well as to the log file")
+	dir, ok := parseArgs(flags, args)
+	if !ok {
+		return 2
+	}
+	defer startLogging(*verbose, stderr)()
+	graph, err := app.BuildGraph(ctx, app.GraphGenerationOptions{Path: dir, Target: target}, nil)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "scan failed: %v\n", err)
+		return 1
+	}
+	for _, u := range findUnresolved(graph, *optional) {
+		_, _ = fmt.Fprintln(stdout, u)
+	}
+	problems := findProblems(graph, *optional)
+	for _, p := range problems {
+		_, _ = fmt.Fprintln(stdout, p)
+	}
+	if len(problems) > 0 {
+		_, _ = fmt.Fprintf(stdout, "%d problem(s) found\n", len(problems))
+		return 1
+	}
+	_, _ = fmt.Fprintln(stdout, "No problems found")
+	return 0
+}
+
+// targetFlags registers the flags describing the target environment. Flags
+// that are left empty fall back to what the modpack declares.
+func targetFlags(flags *flag.FlagSet) *app.TargetEnvironment {
+	var target app.TargetEnvironment
+	flags.StringVar(&target.MinecraftVersion, "minecraft", "", "Minecraft version the pack runs on")
+	flags.StringVar(&target.Loader, "loader", "", "mod loader the pack runs on: forge, neoforge, fabric or quilt")
+	flags.StringVar(&target.LoaderVersion, "loader-version", "", "version of the mod loader")
+	flags.StringVar(&target.JavaVersion, "java", "", "Java version the pack runs on")
+	return &target
+}
+
+// startLogging sets up logging once the flags of a command are parsed, and
+// returns a function closing the log file. Problems are already reported on
+// stdout, so records only go to stderr when verbose.
+func startLogging(verbose bool, stderr io.Writer) func() {
+	var console io.Writer
+	if verbose {
+		console = stderr
+	}
+	closeLog, err := logging.Setup(logging.Options{Name: "cli", Verbose: verbose, Console: console})
+	if err != nil {
+		// Without -verbose nothing is logged to the console, so the warning
+		// would be lost
+		_, _ = fmt.Fprintf(stderr, "warning: cannot open log file: %v\n", err)
+	}
+	return func() {
+		_ = closeLog()
+	}
+}
+
+// parseArgs parses flags and a single positional directory argument. Flags may
+// appear before or after the directory.
+func parseArgs(flags *flag.FlagSet, args []string) (string, bool) {
+	var positional []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return "", false
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if len(positional) != 1 {
+		_, _ = fmt.Fprintf(flags.Output(), "%s: expected exactly one directory argument\n", flags.Name())
+		flags.Usage()
+		return "", false
+	}
+	return positional[0], true
+}
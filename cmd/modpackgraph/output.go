@@ -0,0 +1,190 @@
+package main
+
+import (
+	"ModpackGraph/internal/app"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+func writeGraph(w io.Writer, graph *app.Graph, format string) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(graph)
+	case "text":
+		return writeText(w, graph)
+	case "dot":
+		return writeDOT(w, graph)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func writeText(w io.Writer, graph *app.Graph) error {
+	var b strings.Builder
+	if pack := graph.Pack; pack != nil {
+		_, _ = fmt.Fprintf(&b, "Pack: %s %s\n", pack.Name, pack.Version)
+		_, _ = fmt.Fprintf(&b, "Minecraft: %s, %s %s\n", pack.MinecraftVersion, pack.Loader, pack.LoaderVersion)
+	}
+	b.WriteString("Mods:\n")
+	for _, node := range graph.SortedNodes() {
+		if node.Indexed && node.Optional {
+			_, _ = fmt.Fprintf(&b, "  %s (indexed, optional)\n", node.ID)
+		} else if node.Indexed {
+			_, _ = fmt.Fprintf(&b, "  %s (indexed)\n", node.ID)
+		} else if node.Status == app.StatusVersionMismatch {
+			_, _ = fmt.Fprintf(&b, "  %s %s (version mismatch) %s\n", node.ID, node.PresentVersion, node.RequiredVersion.String())
+		} else if node.Present && len(node.Aliases) > 0 {
+			_, _ = fmt.Fprintf(&b, "  %s %s (provides %s)\n", node.ID, node.PresentVersion, strings.Join(node.Aliases, ", "))
+		} else if node.Present {
+			_, _ = fmt.Fprintf(&b, "  %s %s\n", node.ID, node.PresentVersion)
+		} else {
+			_, _ = fmt.Fprintf(&b, "  %s (%s) %s\n", node.ID, node.Status, node.RequiredVersion.String())
+		}
+	}
+	b.WriteString("Dependencies:\n")
+	for _, edge := range graph.SortedEdges() {
+		_, _ = fmt.Fprintf(&b, "  %s -> %s (%s) %s\n", edge.Source, edge.Target, edge.Relation, edge.Label)
+	}
+	if len(graph.Duplicates) > 0 {
+		b.WriteString("Duplicates:\n")
+		for _, duplicate := range graph.Duplicates {
+			_, _ = fmt.Fprintf(&b, "  %s: %s\n", duplicate.ID, formatInstances(duplicate.Instances))
+		}
+	}
+	if len(graph.Files) > 0 {
+		b.WriteString("Files:\n")
+		for _, file := range graph.Files {
+			_, _ = fmt.Fprintf(&b, "  %s (%d bytes)", file.Path, file.Size)
+			if file.NestedIn != "" {
+				_, _ = fmt.Fprintf(&b, " in %s", file.NestedIn)
+			}
+			if len(file.Mods) > 0 {
+				_, _ = fmt.Fprintf(&b, ": %s", strings.Join(file.Mods, ", "))
+			}
+			b.WriteString("\n")
+		}
+	}
+	if len(graph.Diagnostics) > 0 {
+		b.WriteString("Diagnostics:\n")
+		for _, d := range graph.Diagnostics {
+			_, _ = fmt.Fprintf(&b, "  %s %s (%s): %s\n", d.Severity, d.File, d.Stage, d.Error)
+		}
+	}
+	if len(graph.PlatformViolations) > 0 {
+		b.WriteString("Platform:\n")
+		for _, v := range graph.PlatformViolations {
+			_, _ = fmt.Fprintf(&b, "  %s\n", v.Message)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeDOT(w io.Writer, graph *app.Graph) error {
+	var b strings.Builder
+	b.WriteString("digraph modpack {\n")
+	for _, node := range graph.SortedNodes() {
+		label := node.Label
+		if node.Present && node.PresentVersion != "" {
+			label += "\n" + node.PresentVersion
+		}
+		style := ""
+		if !node.Present {
+			style = ", style=dashed, color=red"
+		}
+		_, _ = fmt.Fprintf(&b, "  %q [label=%q%s];\n", node.ID, label, style)
+	}
+	for _, edge := range graph.SortedEdges() {
+		style := ""
+		if !edge.Required {
+			style = ", style=dashed"
+		}
+		_, _ = fmt.Fprintf(&b, "  %q -> %q [label=%q%s];\n", edge.Source, edge.Target, edge.Label, style)
+	}
+	b.WriteString("}\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// findProblems lists every missing mod that another mod depends on, every
+// installed mod whose version does not satisfy a dependency on it, every mod
+// installed more than once, every unmet platform requirement and installed
+// mod that breaks another one, and every file whose mods could not be read.
+// Optional dependencies and soft conflicts are only reported when
+// includeOptional is set.
+func findProblems(graph *app.Graph, includeOptional bool) []string {
+	problems := findAbsent(graph, app.StatusMissing, includeOptional)
+
+	for _, edge := range graph.SortedEdges() {
+		if edge.Status != app.StatusVersionMismatch || (!edge.Required && !includeOptional) {
+			continue
+		}
+		node, _ := graph.GetNode(edge.Target)
+		problems = append(problems, fmt.Sprintf("%s %s does not match %s, required by %s", node.ID, node.PresentVersion, edge.Label, edge.Source))
+	}
+	for _, edge := range graph.SortedEdges() {
+		if edge.Status != app.StatusConflict || (edge.Relation != app.RelationBreaks && !includeOptional) {
+			continue
+		}
+		node, _ := graph.GetNode(edge.Target)
+		problems = append(problems, fmt.Sprintf("%s %s %s %s", edge.Source, edge.Relation, node.ID, node.PresentVersion))
+	}
+	for _, duplicate := range graph.Duplicates {
+		problems = append(problems, fmt.Sprintf("duplicate %s in %s", duplicate.ID, formatInstances(duplicate.Instances)))
+	}
+	for _, v := range graph.PlatformViolations {
+		problems = append(problems, v.Message)
+	}
+	for _, d := range graph.Diagnostics {
+		if d.Severity == app.SeverityError {
+			problems = append(problems, fmt.Sprintf("cannot read %s (%s): %s", d.File, d.Stage, d.Error))
+		}
+	}
+	return problems
+}
+
+// findUnresolved lists every dependency that no jar read satisfies, but that
+// may be one of the files the pack manifest lists. They are not problems, as
+// the mods of those files are unknown.
+func findUnresolved(graph *app.Graph, includeOptional bool) []string {
+	return findAbsent(graph, app.StatusUnresolved, includeOptional)
+}
+
+// findAbsent describes every mod with the given status that another mod
+// depends on.
+func findAbsent(graph *app.Graph, status app.Status, includeOptional bool) []string {
+	var absent []string
+	for _, node := range graph.SortedNodes() {
+		if node.Present || node.Status != status {
+			continue
+		}
+		var dependents []string
+		for _, edge := range graph.SortedEdges() {
+			if edge.Target != node.ID || (!edge.Required && !includeOptional) {
+				continue
+			}
+			dependents = append(dependents, edge.Source)
+		}
+		if len(dependents) == 0 {
+			continue
+		}
+		line := fmt.Sprintf("%s %s", status, node.ID)
+		if v := node.RequiredVersion.String(); v != "" {
+			line += " " + v
+		}
+		absent = append(absent, fmt.Sprintf("%s, required by %s", line, strings.Join(dependents, ", ")))
+	}
+	return absent
+}
+
+func formatInstances(instances []app.ModInstance) string {
+	var parts []string
+	for _, instance := range instances {
+		parts = append(parts, fmt.Sprintf("%s (%s)", instance.Path, instance.Version))
+	}
+	return strings.Join(parts, ", ")
+}
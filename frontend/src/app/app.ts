@@ -7,7 +7,8 @@ import { Form } from '@/app/models/form';
 import { SelectButton } from 'primeng/selectbutton';
 import { InteractiveTwoTab } from '@components/tabs/interactive-two-tab/interactive-two-tab';
 import { InteractiveThreeTab } from '@components/tabs/interactive-three-tab/interactive-three-tab';
-import { GenerateDependencyGraph } from '@wailsjs/go/app/App';
+import { CancelScan, WatchFolder } from '@wailsjs/go/app/App';
+import { EventsOn } from '@wailsjs/runtime/runtime';
 import * as models from '@wailsjs/go/models';
 import app = models.app
 import { Button } from 'primeng/button';
@@ -17,8 +18,12 @@ import { LanguageService } from '@services/language-service';
 import { Select } from 'primeng/select';
 import Graph = app.Graph;
 import GraphGenerationOptions = app.GraphGenerationOptions;
+import Diagnostic = app.Diagnostic;
 import { ListTab } from '@components/tabs/list-tab/list-tab';
 import { ToggleSwitch } from 'primeng/toggleswitch';
+import { applyGraphDelta, GraphDelta } from '@/app/models/graph-delta';
+import { ScanProgress } from '@/app/models/scan-progress';
+import { ProgressBar } from 'primeng/progressbar';
 
 interface SelectValue {
   label: string;
@@ -42,6 +47,7 @@ interface SelectValue {
     Select,
     ListTab,
     ToggleSwitch,
+    ProgressBar,
   ],
   providers: [
     MessageService,
@@ -71,6 +77,8 @@ export class App {
     }
   ];
   protected graphData?: Graph;
+  protected scanProgress?: ScanProgress;
+  private scanning = false;
 
   protected graphDisplayOptions: GraphDisplayOptions = {
     showIcons: true,
@@ -121,6 +129,22 @@ export class App {
     this.listDisplayForm.valueChanges.subscribe(value => {
       this.listDisplayOptions = value as ListDisplayOptions;
     });
+    // The selected pack is watched after it is processed, so the graph follows
+    // mods being added or removed
+    EventsOn('graph:delta', (delta: GraphDelta) => {
+      if (this.graphData) {
+        this.graphData = applyGraphDelta(this.graphData, delta);
+      }
+    });
+    // Progress events can arrive after the scan returned
+    EventsOn('scan:progress', (progress: ScanProgress) => {
+      if (this.scanning) {
+        this.scanProgress = progress;
+      }
+    });
+    EventsOn('graph:watch-error', (error: string) => {
+      this.messageService.add({severity: 'error', summary: $localize`Something went wrong.`, detail: `Error: ${error}`});
+    });
   }
 
 
@@ -130,13 +154,42 @@ export class App {
       return;
     }
     this.messageService.add({severity: 'info', summary: $localize`Generating graph`, detail: $localize`Generating graph...`});
+    this.scanning = true;
     try {
-      this.graphData = await GenerateDependencyGraph(graphOptions as GraphGenerationOptions);
+      this.graphData = await WatchFolder(graphOptions as GraphGenerationOptions);
       this.messageService.add({severity: 'success', summary: $localize`Graph generated`, detail: $localize`Graph generated successfully.`});
+      this.showDiagnostics(this.graphData.diagnostics);
     } catch (error) {
       this.messageService.add({severity: 'error', summary: $localize`Something went wrong.`, detail: `Error: ${error}`});
       console.error("Error generating graph:", error);
+    } finally {
+      this.scanning = false;
+      this.scanProgress = undefined;
+    }
+  }
+
+  // Files that could not be read are otherwise only missing from the graph
+  private showDiagnostics(diagnostics?: Diagnostic[]) {
+    const problems = (diagnostics ?? []).filter(d => d.severity !== 'info');
+    if (problems.length === 0) {
+      return;
+    }
+    this.messageService.add({
+      severity: 'warn',
+      summary: $localize`Something went wrong.`,
+      detail: problems.map(d => `${d.file} (${d.stage}): ${d.error}`).join('; '),
+    });
+  }
+
+  protected async onCancelScan() {
+    await CancelScan();
+  }
+
+  protected get scanPercent(): number {
+    if (!this.scanProgress?.discovered) {
+      return 0;
     }
+    return Math.round(100 * this.scanProgress.processed / this.scanProgress.discovered);
   }
 
   protected setLanguage(lang: string) {
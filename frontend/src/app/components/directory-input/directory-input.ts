@@ -4,7 +4,7 @@ import { AbstractControl, FormsModule, ReactiveFormsModule } from "@angular/form
 import { InputGroup } from "primeng/inputgroup";
 import { InputGroupAddon } from "primeng/inputgroupaddon";
 import { InputText } from "primeng/inputtext";
-import { OpenDirectoryDialog } from '@wailsjs/go/app/App';
+import { OpenDirectoryDialog, OpenFileDialog } from '@wailsjs/go/app/App';
 import { Tooltip } from 'primeng/tooltip';
 
 @Component({
@@ -25,6 +25,10 @@ export class DirectoryInput implements OnInit {
   @Input() control: AbstractControl | null = null;
   @Input() placeholder: string = 'Select directory';
   @Input() title: string = 'Select Directory';
+  @Input() fileTitle: string = 'Select Modpack File';
+  @Input() fileFilters: { displayName: string, pattern: string }[] = [
+    { displayName: 'Modpack files', pattern: '*.mrpack;*.zip' },
+  ];
 
   @ViewChild('dirInput') dirInput!: ElementRef<HTMLInputElement>
 
@@ -52,4 +56,19 @@ export class DirectoryInput implements OnInit {
       console.error("Error selecting folder:", error);
     }
   }
+
+  protected async onSelectFile() {
+    try {
+      this.value = await OpenFileDialog({
+        title: this.fileTitle,
+        filters: this.fileFilters,
+      });
+      if (this.value) {
+        this.control?.setValue(this.value);
+        this.folderSelected.emit(this.value);
+      }
+    } catch (error) {
+      console.error("Error selecting file:", error);
+    }
+  }
 }
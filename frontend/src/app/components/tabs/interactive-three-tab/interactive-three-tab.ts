@@ -83,7 +83,10 @@ export class InteractiveThreeTab implements OnInit {
           .width(rect.width)
           .height(rect.height)
           .d3AlphaDecay(0.1)
-          .linkLabel((link: Pick<Edge, 'label' | 'required'> & LinkObject) => {
+          .linkLabel((link: Pick<Edge, 'label' | 'required' | 'relation'> & LinkObject) => {
+            if (link.relation === 'bundled_by') {
+              return link.label;
+            }
             return link.required ? $localize`Required: ${link.label}` : $localize`Optional: ${link.label}`;
           })
           .linkWidth(1)
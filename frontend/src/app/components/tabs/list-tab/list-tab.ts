@@ -14,6 +14,9 @@ interface Mod {
   present: boolean;
   required: boolean;
   requiredVersion: string;
+  versionMismatch: boolean;
+  // Unresolved mods may be one of the files the pack manifest lists
+  unresolved: boolean;
   iconURL?: string;
 }
 
@@ -52,14 +55,25 @@ export class ListTab implements OnChanges, OnInit {
         present: isPresent,
         required: isRequired,
         requiredVersion: node.requiredVersion ?? '',
+        versionMismatch: node.status === 'version_mismatch',
+        unresolved: node.status === 'unresolved',
         iconURL: node.icon,
       });
       this.mods.sort((a, b) => {
         // Missing required mods first
-        if (a.required && !a.present && !(b.required && !b.present)) {
+        const aMissing = a.required && !a.present && !a.unresolved;
+        const bMissing = b.required && !b.present && !b.unresolved;
+        if (aMissing && !bMissing) {
           return -1;
         }
-        if (b.required && !b.present && !(a.required && !a.present)) {
+        if (bMissing && !aMissing) {
+          return 1;
+        }
+        // Then installed mods with an unmet version constraint
+        if (a.versionMismatch && !b.versionMismatch) {
+          return -1;
+        }
+        if (b.versionMismatch && !a.versionMismatch) {
           return 1;
         }
         // Then missing optional mods
@@ -80,7 +94,7 @@ export class ListTab implements OnChanges, OnInit {
     if (!mod) {
       return 'warn';
     }
-    if (mod.required && !mod.present) {
+    if ((mod.required && !mod.present && !mod.unresolved) || mod.versionMismatch) {
       return 'danger';
     }
     if (mod.present) {
@@ -0,0 +1,60 @@
+import * as models from '@wailsjs/go/models';
+import app = models.app
+
+// Changes to a watched pack's graph, as emitted by the "graph:delta" event
+export interface GraphDelta {
+  nodes?: app.Node[];
+  removedNodes?: string[];
+  links?: app.Edge[];
+  removedLinks?: app.Edge[];
+  files?: app.ModFile[];
+  duplicates?: app.Duplicate[];
+  pack?: app.PackInfo;
+  target: app.TargetEnvironment;
+  platformViolations?: app.PlatformViolation[];
+  diagnostics?: app.Diagnostic[];
+}
+
+// The graph view replaces the source and target of the links it is given with
+// their nodes, so either may be a node rather than its ID
+const endpointId = (endpoint: unknown) => typeof endpoint === 'object' && endpoint !== null
+  ? `${(endpoint as app.Node).id}`
+  : `${endpoint}`;
+
+// Matches Edge.key on the Go side: incompatibilities are keyed apart from the
+// dependency the same mod may declare on the same target
+const edgeKey = (edge: app.Edge) => {
+  const source = endpointId(edge.source);
+  const target = endpointId(edge.target);
+  return edge.relation === 'breaks' || edge.relation === 'conflicts'
+    ? `${source}-${edge.relation}->${target}`
+    : `${source}->${target}`;
+};
+
+// Returns a new graph with the delta applied, leaving the given one untouched
+export function applyGraphDelta(graph: app.Graph, delta: GraphDelta): app.Graph {
+  const removedNodes = new Set(delta.removedNodes ?? []);
+  const nodes = new Map(graph.nodes
+    .filter(node => !removedNodes.has(`${node.id}`))
+    .map((node): [string, app.Node] => [`${node.id}`, node]));
+  for (const node of delta.nodes ?? []) {
+    nodes.set(`${node.id}`, node);
+  }
+  const removedLinks = new Set((delta.removedLinks ?? []).map(edgeKey));
+  const links = new Map(graph.links
+    .filter(edge => !removedLinks.has(edgeKey(edge)))
+    .map((edge): [string, app.Edge] => [edgeKey(edge), edge]));
+  for (const edge of delta.links ?? []) {
+    links.set(edgeKey(edge), edge);
+  }
+  return {
+    nodes: [...nodes.values()],
+    links: [...links.values()],
+    files: delta.files ?? undefined,
+    duplicates: delta.duplicates ?? undefined,
+    pack: delta.pack ?? undefined,
+    target: delta.target,
+    platformViolations: delta.platformViolations ?? undefined,
+    diagnostics: delta.diagnostics ?? undefined,
+  };
+}
@@ -0,0 +1,6 @@
+// Progress of a scan, as emitted by the "scan:progress" event
+export interface ScanProgress {
+  discovered: number;
+  processed: number;
+  current?: string;
+}
@@ -3,8 +3,16 @@
 import {app} from '../models';
 import {menu} from '../models';
 
+export function CancelScan():Promise<void>;
+
 export function GenerateDependencyGraph(arg1:app.GraphGenerationOptions):Promise<app.Graph>;
 
 export function Menu():Promise<menu.Menu>;
 
 export function OpenDirectoryDialog(arg1:app.OpenDialogOptions):Promise<string>;
+
+export function OpenFileDialog(arg1:app.OpenDialogOptions):Promise<string>;
+
+export function StopWatching():Promise<void>;
+
+export function WatchFolder(arg1:app.GraphGenerationOptions):Promise<app.Graph>;
@@ -2,6 +2,10 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function CancelScan() {
+  return window['go']['app']['App']['CancelScan']();
+}
+
 export function GenerateDependencyGraph(arg1) {
   return window['go']['app']['App']['GenerateDependencyGraph'](arg1);
 }
@@ -13,3 +17,15 @@ export function Menu() {
 export function OpenDirectoryDialog(arg1) {
   return window['go']['app']['App']['OpenDirectoryDialog'](arg1);
 }
+
+export function OpenFileDialog(arg1) {
+  return window['go']['app']['App']['OpenFileDialog'](arg1);
+}
+
+export function StopWatching() {
+  return window['go']['app']['App']['StopWatching']();
+}
+
+export function WatchFolder(arg1) {
+  return window['go']['app']['App']['WatchFolder'](arg1);
+}
@@ -1,10 +1,31 @@
 export namespace app {
 	
+	export interface Component {
+	    uid: string;
+	    name?: string;
+	    version?: string;
+	}
+	export interface Diagnostic {
+	    file: string;
+	    stage: string;
+	    severity: string;
+	    error: string;
+	}
+	export interface Duplicate {
+	    id: string;
+	    instances: ModInstance[];
+	}
 	export interface Edge {
 	    source: string;
 	    target: string;
 	    label?: string;
 	    required?: boolean;
+	    relation?: string;
+	    status?: string;
+	}
+	export interface Env {
+	    client?: string;
+	    server?: string;
 	}
 	export interface FileFilter {
 	    displayName: string;
@@ -13,9 +34,28 @@ export namespace app {
 	export interface Graph {
 	    nodes: Node[];
 	    links: Edge[];
+	    files?: ModFile[];
+	    duplicates?: Duplicate[];
+	    pack?: PackInfo;
+	    target: TargetEnvironment;
+	    platformViolations?: PlatformViolation[];
+	    diagnostics?: Diagnostic[];
 	}
 	export interface GraphGenerationOptions {
 	    path?: string;
+	    target?: TargetEnvironment;
+	}
+	export interface ModFile {
+	    path: string;
+	    size?: number;
+	    hashes?: {[key: string]: string};
+	    nestedIn?: string;
+	    bundles?: string[];
+	    mods?: string[];
+	}
+	export interface ModInstance {
+	    path: string;
+	    version?: string;
 	}
 	export interface Node {
 	    id?: string | number;
@@ -24,6 +64,15 @@ export namespace app {
 	    present?: boolean;
 	    presentVersion?: string;
 	    requiredVersion?: string;
+	    status?: string;
+	    aliases?: string[];
+	    indexed?: boolean;
+	    optional?: boolean;
+	    path?: string;
+	    projectId?: string;
+	    fileId?: string;
+	    hashes?: {[key: string]: string};
+	    env?: Env;
 	}
 	export interface OpenDialogOptions {
 	    title?: string;
@@ -35,6 +84,29 @@ export namespace app {
 	    resolvesAliases?: boolean;
 	    treatPackagesAsDirectories?: boolean;
 	}
+	export interface PackInfo {
+	    name?: string;
+	    version?: string;
+	    minecraftVersion?: string;
+	    loader?: string;
+	    loaderVersion?: string;
+	    javaVersion?: string;
+	    components?: Component[];
+	}
+	export interface PlatformViolation {
+	    mod: string;
+	    platform: string;
+	    relation: string;
+	    required: string;
+	    actual: string;
+	    message: string;
+	}
+	export interface TargetEnvironment {
+	    minecraftVersion?: string;
+	    loader?: string;
+	    loaderVersion?: string;
+	    javaVersion?: string;
+	}
 
 }
 
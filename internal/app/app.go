@@ -3,6 +3,8 @@ package app
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	"sync"
 
 	"github.com/wailsapp/wails/v2/pkg/menu"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -12,6 +14,10 @@ import (
 type App struct {
 	ctx    context.Context
 	config Config
+
+	mu         sync.Mutex
+	watcher    *packWatcher
+	cancelScan context.CancelFunc
 }
 
 func NewApp(config Config) *App {
@@ -48,8 +54,94 @@ func (a *App) OpenDirectoryDialog(options OpenDialogOptions) (string, error) {
 	})
 }
 
+func (a *App) OpenFileDialog(options OpenDialogOptions) (string, error) {
+	filters := make([]runtime.FileFilter, 0, len(options.Filters))
+	for _, f := range options.Filters {
+		filters = append(filters, runtime.FileFilter{
+			DisplayName: f.DisplayName,
+			Pattern:     f.Pattern,
+		})
+	}
+	return runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title:            options.Title,
+		DefaultDirectory: options.DefaultDirectory,
+		Filters:          filters,
+	})
+}
+
+// GenerateDependencyGraph scans the pack described by options. Its progress
+// is emitted as "scan:progress" events, and it can be aborted with CancelScan.
 func (a *App) GenerateDependencyGraph(options GraphGenerationOptions) (*Graph, error) {
-	return scanModFolder(options.Path)
+	ctx, cancel := a.startScan()
+	defer cancel()
+	return BuildGraph(ctx, options, a.emitProgress)
+}
+
+// CancelScan aborts the scan in progress, if any. The aborted call returns
+// an error.
+func (a *App) CancelScan() {
+	a.mu.Lock()
+	cancel := a.cancelScan
+	a.mu.Unlock()
+	if cancel != nil {
+		slog.Info("Cancelling scan")
+		cancel()
+	}
+}
+
+// startScan returns the context of a new scan, which CancelScan cancels.
+func (a *App) startScan() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(a.ctx)
+	a.mu.Lock()
+	a.cancelScan = cancel
+	a.mu.Unlock()
+	return ctx, cancel
+}
+
+func (a *App) emitProgress(progress ScanProgress) {
+	runtime.EventsEmit(a.ctx, "scan:progress", progress)
+}
+
+// WatchFolder generates the dependency graph described by options like
+// GenerateDependencyGraph, then keeps watching the pack it was read from.
+// Whenever the pack changes, the changes to the graph are emitted as a
+// "graph:delta" event, and rescans that fail as a "graph:watch-error" event.
+// Any previous watch is stopped.
+func (a *App) WatchFolder(options GraphGenerationOptions) (*Graph, error) {
+	a.StopWatching()
+	ctx, cancel := a.startScan()
+	defer cancel()
+	watcher, graph, err := newPackWatcher(ctx, options, a.emitProgress)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	previous := a.watcher
+	a.watcher = watcher
+	a.mu.Unlock()
+	if previous != nil {
+		previous.close()
+	}
+	go watcher.run(func(delta GraphDelta) {
+		runtime.EventsEmit(a.ctx, "graph:delta", delta)
+	}, func(err error) {
+		slog.Warn("Failed to rescan watched pack", "path", options.Path, "error", err)
+		runtime.EventsEmit(a.ctx, "graph:watch-error", err.Error())
+	})
+	slog.Info("Watching pack", "path", options.Path)
+	return graph, nil
+}
+
+// StopWatching stops watching the pack of the last WatchFolder call, if any.
+func (a *App) StopWatching() {
+	a.mu.Lock()
+	watcher := a.watcher
+	a.watcher = nil
+	a.mu.Unlock()
+	if watcher != nil {
+		watcher.close()
+		slog.Info("Stopped watching pack", "path", watcher.options.Path)
+	}
 }
 
 func (a *App) Menu() *menu.Menu {
@@ -0,0 +1,312 @@
+package app
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"encoding/gob"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// scanCacheVersion is bumped whenever the metadata read from jars changes
+// shape, so that stale caches are discarded instead of misread.
+const scanCacheVersion = 5
+
+// scanCache remembers the metadata read from jars between scans, so that
+// rescanning a pack only reads the jars that were added or changed. Jars on
+// disk are keyed by their absolute path, and jars stored in a pack archive by
+// the absolute path of the archive followed by their name in it.
+type scanCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+	dirty   bool
+}
+
+type scanCacheFile struct {
+	Version int
+	Entries map[string]*cacheEntry
+}
+
+// cacheEntry is what was read from a jar, along with what identified its
+// content at the time.
+type cacheEntry struct {
+	// Path is the path the jars were read under, which differs from the key
+	// when the same jar is reached through another path
+	Path    string
+	Size    int64
+	ModTime int64
+	SHA1    string
+	// CRC32 identifies the content of a jar stored in a pack archive, which
+	// has no modification time of its own
+	CRC32 uint32
+	// Jars holds the jar and every jar bundled in it
+	Jars []cachedJar
+}
+
+type cachedJar struct {
+	File        ModFile
+	Metas       []ModMetadata
+	Diagnostics []Diagnostic
+}
+
+// scanCachePath is where the scan cache is stored, under the user cache dir.
+func scanCachePath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ModpackGraph", "scan-cache.gob"), nil
+}
+
+// newScanCache returns an empty cache saved to path. A cache without a path
+// is only kept in memory.
+func newScanCache(path string) *scanCache {
+	return &scanCache{path: path, entries: make(map[string]*cacheEntry)}
+}
+
+// loadScanCache reads the scan cache from disk. A missing, unreadable or
+// outdated cache is replaced by an empty one. It returns nil when there is no
+// place to store a cache, in which case every jar is read.
+func loadScanCache() *scanCache {
+	cachePath, err := scanCachePath()
+	if err != nil {
+		slog.Warn("No scan cache directory available", "error", err)
+		return nil
+	}
+	cache := newScanCache(cachePath)
+	f, err := os.Open(cachePath)
+	if err != nil {
+		slog.Debug("Starting with an empty scan cache", "path", cachePath, "error", err)
+		return cache
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	var data scanCacheFile
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		slog.Warn("Discarding unreadable scan cache", "path", cachePath, "error", err)
+		return cache
+	}
+	if data.Version != scanCacheVersion {
+		slog.Info("Discarding outdated scan cache", "path", cachePath, "version", data.Version)
+		return cache
+	}
+	if data.Entries != nil {
+		cache.entries = data.Entries
+	}
+	slog.Debug("Loaded scan cache", "path", cachePath, "entries", len(cache.entries))
+	return cache
+}
+
+// save writes the cache back to disk if it changed, dropping the entries of
+// jars that no longer exist. A cache kept in memory is only pruned.
+func (c *scanCache) save() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for p := range c.entries {
+		// Jars stored in a pack archive live as long as the archive
+		file, _, _ := strings.Cut(p, "!/")
+		if _, err := os.Stat(file); err != nil {
+			delete(c.entries, p)
+			c.dirty = true
+		}
+	}
+	if !c.dirty || c.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	// Write to a temporary file first so that an interrupted write never
+	// leaves a truncated cache behind
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), "scan-cache-*.tmp")
+	if err != nil {
+		return err
+	}
+	err = gob.NewEncoder(tmp).Encode(scanCacheFile{Version: scanCacheVersion, Entries: c.entries})
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	c.dirty = false
+	slog.Debug("Saved scan cache", "path", c.path, "entries", len(c.entries))
+	return nil
+}
+
+// cacheKey is the key of the jar at jarPath, which is the same whichever
+// working directory the jar is reached from.
+func cacheKey(jarPath string) string {
+	if abs, err := filepath.Abs(jarPath); err == nil {
+		return abs
+	}
+	return jarPath
+}
+
+// getModJars reads a jar from disk like getModJars, unless the cache already
+// holds what was read from it. A cached entry is used when the jar has the
+// same size and modification time as when it was read, or the same size and
+// content if only its modification time changed.
+func (c *scanCache) getModJars(jarPath string) (map[string]*modJar, error) {
+	if c == nil {
+		return getModJars(jarPath)
+	}
+	stat, err := os.Stat(jarPath)
+	if err != nil {
+		return nil, err
+	}
+	key := cacheKey(jarPath)
+	if jars, ok := c.lookup(key, jarPath, stat); ok {
+		slog.Debug("Read jar from scan cache", "file", jarPath)
+		return jars, nil
+	}
+	jars, err := getModJars(jarPath)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, &cacheEntry{
+		Path:    jarPath,
+		Size:    stat.Size(),
+		ModTime: stat.ModTime().UnixNano(),
+		SHA1:    jars[jarPath].Hashes["sha1"],
+	}, jars)
+	return jars, nil
+}
+
+// getZipEntryJars reads a jar stored in a pack archive like readZipEntryJars,
+// unless the cache already holds what was read from the same entry of the
+// archive with the same size and checksum.
+func (c *scanCache) getZipEntryJars(archivePath string, archive io.ReaderAt, f *zip.File) (map[string]*modJar, error) {
+	if c == nil {
+		return readZipEntryJars(f.Name, archive, f)
+	}
+	key := nestedJarPath(cacheKey(archivePath), f.Name)
+	size := int64(f.UncompressedSize64)
+	if entry, ok := c.entry(key); ok && entry.Size == size && entry.CRC32 == f.CRC32 {
+		slog.Debug("Read jar from scan cache", "file", key)
+		return entry.modJars(f.Name), nil
+	}
+	jars, err := readZipEntryJars(f.Name, archive, f)
+	if err != nil {
+		return nil, err
+	}
+	c.store(key, &cacheEntry{
+		Path:  f.Name,
+		Size:  size,
+		SHA1:  jars[f.Name].Hashes["sha1"],
+		CRC32: f.CRC32,
+	}, jars)
+	return jars, nil
+}
+
+// entry returns a copy of the entry stored under key, which stays valid while
+// other scans update the cache.
+func (c *scanCache) entry(key string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok {
+		return cacheEntry{}, false
+	}
+	return *entry, true
+}
+
+func (c *scanCache) lookup(key, jarPath string, stat fs.FileInfo) (map[string]*modJar, bool) {
+	entry, ok := c.entry(key)
+	if !ok || entry.Size != stat.Size() {
+		return nil, false
+	}
+	if entry.ModTime != stat.ModTime().UnixNano() {
+		sum, err := fileSHA1(jarPath)
+		if err != nil || sum != entry.SHA1 {
+			return nil, false
+		}
+		c.mu.Lock()
+		if current, ok := c.entries[key]; ok && current.SHA1 == sum {
+			current.ModTime = stat.ModTime().UnixNano()
+			c.dirty = true
+		}
+		c.mu.Unlock()
+	}
+	return entry.modJars(jarPath), true
+}
+
+func (c *scanCache) store(key string, entry *cacheEntry, jars map[string]*modJar) {
+	for _, jar := range jars {
+		entry.Jars = append(entry.Jars, cachedJar{File: jar.ModFile, Metas: jar.metas, Diagnostics: jar.diagnostics})
+	}
+	c.mu.Lock()
+	c.entries[key] = entry
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+// modJars rebuilds the jars of the entry as read under jarPath.
+func (entry cacheEntry) modJars(jarPath string) map[string]*modJar {
+	jars := make(map[string]*modJar, len(entry.Jars))
+	for _, cached := range entry.Jars {
+		jar := &modJar{ModFile: cached.File, metas: cached.Metas, diagnostics: cached.Diagnostics}
+		if jarPath != entry.Path {
+			jar.rebase(entry.Path, jarPath)
+		}
+		jars[jar.Path] = jar
+	}
+	return jars
+}
+
+// rebase moves a jar read from the path from, or bundled in it, to the same
+// place under the path to.
+func (jar *modJar) rebase(from, to string) {
+	move := func(p string) string {
+		if rest, ok := strings.CutPrefix(p, from); ok && (rest == "" || strings.HasPrefix(rest, "!/")) {
+			return to + rest
+		}
+		return p
+	}
+	jar.Path = move(jar.Path)
+	jar.NestedIn = move(jar.NestedIn)
+	jar.Bundles = slices.Clone(jar.Bundles)
+	for i := range jar.Bundles {
+		jar.Bundles[i] = move(jar.Bundles[i])
+	}
+	jar.metas = slices.Clone(jar.metas)
+	for i := range jar.metas {
+		jar.metas[i].Path = move(jar.metas[i].Path)
+	}
+	jar.diagnostics = slices.Clone(jar.diagnostics)
+	for i := range jar.diagnostics {
+		jar.diagnostics[i].File = move(jar.diagnostics[i].File)
+	}
+}
+
+func fileSHA1(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := sha1.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,84 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestScanCacheKeysByAbsolutePath(t *testing.T) {
+	dir := t.TempDir()
+	jarPath := filepath.Join(dir, "mod.jar")
+	jar := zipJar(t, map[string]string{
+		"fabric.mod.json": `{"id": "mod", "version": "1.0"}`,
+	})
+	if err := os.WriteFile(jarPath, jar, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cache := &scanCache{entries: make(map[string]*cacheEntry)}
+	if _, err := cache.getModJars(jarPath); err != nil {
+		t.Fatal(err)
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = os.Chdir(wd)
+	}()
+	// Reading through a relative path hits the entry stored by the absolute
+	// one, with the paths of the jar rebased onto the relative path
+	cache.entries[cacheKey(jarPath)].Jars[0].Metas[0].Name = "cached"
+	jars, err := cache.getModJars("mod.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, ok := jars["mod.jar"]
+	if !ok || len(read.metas) != 1 || read.metas[0].Name != "cached" || read.metas[0].Path != "mod.jar" {
+		t.Errorf("read %+v from cache, want the cached mod under mod.jar", jars)
+	}
+	if len(cache.entries) != 1 {
+		t.Errorf("cache has %d entries, want 1", len(cache.entries))
+	}
+}
+
+func TestScanCacheZipEntries(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("overrides/mods/mod.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write(zipJar(t, map[string]string{
+		"fabric.mod.json": `{"id": "mod", "version": "1.0"}`,
+	})); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := r.File[0]
+
+	cache := &scanCache{entries: make(map[string]*cacheEntry)}
+	if _, err := cache.getZipEntryJars("pack.mrpack", bytes.NewReader(buf.Bytes()), entry); err != nil {
+		t.Fatal(err)
+	}
+	// A second read of the same entry never touches the archive
+	jars, err := cache.getZipEntryJars("pack.mrpack", bytes.NewReader(nil), entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read, ok := jars[entry.Name]; !ok || len(read.metas) != 1 || read.metas[0].ID != "mod" {
+		t.Errorf("read %+v from cache, want mod", jars)
+	}
+}
@@ -0,0 +1,578 @@
+package app
+
+import (
+	"ModpackGraph/internal/util"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// versionInterval is a single contiguous range of versions. An empty bound
+// leaves that side of the interval open.
+type versionInterval struct {
+	minVersion string
+	maxVersion string
+	includeMin bool
+	includeMax bool
+}
+
+func (iv versionInterval) unbounded() bool {
+	return iv.minVersion == "" && iv.maxVersion == ""
+}
+
+func (iv versionInterval) String() string {
+	left := "("
+	if iv.includeMin {
+		left = "["
+	}
+	right := ")"
+	if iv.includeMax {
+		right = "]"
+	}
+	if iv.minVersion != "" && iv.minVersion == iv.maxVersion && iv.includeMin && iv.includeMax {
+		return fmt.Sprintf("[%s]", iv.minVersion)
+	}
+	if iv.minVersion != "" && iv.maxVersion != "" {
+		return fmt.Sprintf("%s%s, %s%s", left, iv.minVersion, iv.maxVersion, right)
+	} else if iv.minVersion != "" {
+		return fmt.Sprintf("%s%s,%s", left, iv.minVersion, right)
+	}
+	return fmt.Sprintf("%s,%s%s", left, iv.maxVersion, right)
+}
+
+// Compat is a set of acceptable versions, expressed as a union of intervals.
+// The zero value accepts every version.
+type Compat struct {
+	intervals []versionInterval
+	// none marks a set that no version can satisfy, such as the intersection
+	// of two disjoint ranges.
+	none   bool
+	scheme VersionScheme
+}
+
+// newCompat builds a Compat from the given intervals. Any unbounded interval
+// makes the whole set unconstrained.
+func newCompat(scheme VersionScheme, intervals ...versionInterval) Compat {
+	c := Compat{scheme: scheme}
+	for _, iv := range intervals {
+		if iv.unbounded() {
+			return c
+		}
+	}
+	if len(intervals) == 0 {
+		return c
+	}
+	c.intervals = intervals
+	c.normalize()
+	return c
+}
+
+// IsAny reports whether every version satisfies the range.
+func (c *Compat) IsAny() bool {
+	return !c.none && len(c.intervals) == 0
+}
+
+// IsNone reports whether no version can satisfy the range.
+func (c *Compat) IsNone() bool {
+	return c.none
+}
+
+func (c *Compat) compareMin(a, b versionInterval) int {
+	switch {
+	case a.minVersion == "" && b.minVersion == "":
+		return 0
+	case a.minVersion == "":
+		return -1
+	case b.minVersion == "":
+		return 1
+	}
+	if cmp := CompareVersions(c.scheme, a.minVersion, b.minVersion); cmp != 0 {
+		return cmp
+	}
+	// An inclusive lower bound starts before an exclusive one
+	return compareInts(boolToInt(!a.includeMin), boolToInt(!b.includeMin))
+}
+
+func (c *Compat) compareMax(a, b versionInterval) int {
+	switch {
+	case a.maxVersion == "" && b.maxVersion == "":
+		return 0
+	case a.maxVersion == "":
+		return 1
+	case b.maxVersion == "":
+		return -1
+	}
+	if cmp := CompareVersions(c.scheme, a.maxVersion, b.maxVersion); cmp != 0 {
+		return cmp
+	}
+	// An inclusive upper bound ends after an exclusive one
+	return compareInts(boolToInt(a.includeMax), boolToInt(b.includeMax))
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// isEmpty reports whether an interval contains no version at all.
+func (c *Compat) isEmpty(iv versionInterval) bool {
+	if iv.minVersion == "" || iv.maxVersion == "" {
+		return false
+	}
+	cmp := CompareVersions(c.scheme, iv.minVersion, iv.maxVersion)
+	return cmp > 0 || (cmp == 0 && !(iv.includeMin && iv.includeMax))
+}
+
+// touches reports whether b starts before a ends, so that both can be merged
+// into a single interval. Both intervals are assumed to be sorted by minimum.
+func (c *Compat) touches(a, b versionInterval) bool {
+	if a.maxVersion == "" || b.minVersion == "" {
+		return true
+	}
+	cmp := CompareVersions(c.scheme, b.minVersion, a.maxVersion)
+	return cmp < 0 || (cmp == 0 && (a.includeMax || b.includeMin))
+}
+
+// normalize sorts the intervals and merges overlapping ones.
+func (c *Compat) normalize() {
+	var intervals []versionInterval
+	for _, iv := range c.intervals {
+		if !c.isEmpty(iv) {
+			intervals = append(intervals, iv)
+		}
+	}
+	if len(intervals) == 0 {
+		c.intervals = nil
+		c.none = true
+		return
+	}
+	sort.SliceStable(intervals, func(i, j int) bool {
+		return c.compareMin(intervals[i], intervals[j]) < 0
+	})
+	merged := intervals[:1]
+	for _, iv := range intervals[1:] {
+		last := &merged[len(merged)-1]
+		if !c.touches(*last, iv) {
+			merged = append(merged, iv)
+			continue
+		}
+		if c.compareMax(iv, *last) > 0 {
+			last.maxVersion = iv.maxVersion
+			last.includeMax = iv.includeMax
+		}
+	}
+	if len(merged) == 1 && merged[0].unbounded() {
+		merged = nil
+	}
+	c.intervals = merged
+}
+
+// Intersect returns the versions accepted by both ranges.
+func (c *Compat) Intersect(other Compat) Compat {
+	if c.none || other.none {
+		return Compat{none: true, scheme: c.scheme}
+	}
+	if c.IsAny() {
+		return other
+	}
+	if other.IsAny() {
+		return *c
+	}
+	result := Compat{scheme: c.scheme}
+	for _, a := range c.intervals {
+		for _, b := range other.intervals {
+			iv := a
+			if result.compareMin(b, iv) > 0 {
+				iv.minVersion = b.minVersion
+				iv.includeMin = b.includeMin
+			}
+			if result.compareMax(b, iv) < 0 {
+				iv.maxVersion = b.maxVersion
+				iv.includeMax = b.includeMax
+			}
+			result.intervals = append(result.intervals, iv)
+		}
+	}
+	result.normalize()
+	return result
+}
+
+// Union returns the versions accepted by either range.
+func (c *Compat) Union(other Compat) Compat {
+	if c.none {
+		return other
+	}
+	if other.none {
+		return *c
+	}
+	if c.IsAny() || other.IsAny() {
+		return Compat{scheme: c.scheme}
+	}
+	result := Compat{scheme: c.scheme}
+	result.intervals = append(append(result.intervals, c.intervals...), other.intervals...)
+	result.normalize()
+	return result
+}
+
+// Contains reports whether version lies within the range.
+func (c *Compat) Contains(version string) bool {
+	if c.none {
+		return false
+	}
+	if len(c.intervals) == 0 {
+		return true
+	}
+	for _, iv := range c.intervals {
+		if c.intervalContains(iv, version) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Compat) intervalContains(iv versionInterval, version string) bool {
+	if iv.minVersion != "" {
+		cmp := CompareVersions(c.scheme, version, iv.minVersion)
+		if cmp < 0 || (cmp == 0 && !iv.includeMin) {
+			return false
+		}
+	}
+	if iv.maxVersion != "" {
+		cmp := CompareVersions(c.scheme, version, iv.maxVersion)
+		if cmp > 0 || (cmp == 0 && !iv.includeMax) {
+			return false
+		}
+	}
+	return true
+}
+
+// emptyRange is how a Compat that no version satisfies is written. Maven has
+// no syntax for it, so it is only understood by parseMavenRange.
+const emptyRange = "()"
+
+// parseMavenRange parses a Maven version range specification such as
+// "[1.0,2.0)", "[1.2.3]" or "(,1.0],[1.2,)". A bare version is only a
+// recommendation in Maven and therefore accepts any version. The empty range
+// written by Compat.String is read back as a set no version satisfies.
+func parseMavenRange(spec string) (Compat, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "*" {
+		return Compat{}, nil
+	}
+	if spec == emptyRange {
+		return Compat{none: true, scheme: MavenScheme}, nil
+	}
+	if spec[0] != '[' && spec[0] != '(' {
+		if strings.ContainsAny(spec, "[](),") {
+			return Compat{}, fmt.Errorf("invalid version range %q", spec)
+		}
+		return Compat{}, nil
+	}
+	var intervals []versionInterval
+	rest := spec
+	for rest != "" {
+		if rest[0] != '[' && rest[0] != '(' {
+			return Compat{}, fmt.Errorf("invalid version range %q: expected '[' or '('", spec)
+		}
+		end := strings.IndexAny(rest, "])")
+		if end < 0 {
+			return Compat{}, fmt.Errorf("invalid version range %q: unterminated interval", spec)
+		}
+		iv := versionInterval{
+			includeMin: rest[0] == '[',
+			includeMax: rest[end] == ']',
+		}
+		bounds := rest[1:end]
+		if i := strings.IndexByte(bounds, ','); i >= 0 {
+			if strings.IndexByte(bounds[i+1:], ',') >= 0 {
+				return Compat{}, fmt.Errorf("invalid version range %q: too many bounds", spec)
+			}
+			iv.minVersion = strings.TrimSpace(bounds[:i])
+			iv.maxVersion = strings.TrimSpace(bounds[i+1:])
+		} else {
+			version := strings.TrimSpace(bounds)
+			if !iv.includeMin || !iv.includeMax || version == "" {
+				return Compat{}, fmt.Errorf("invalid version range %q: single versions must be enclosed in []", spec)
+			}
+			iv.minVersion = version
+			iv.maxVersion = version
+		}
+		// Open bounds are never inclusive
+		iv.includeMin = iv.includeMin && iv.minVersion != ""
+		iv.includeMax = iv.includeMax && iv.maxVersion != ""
+		intervals = append(intervals, iv)
+
+		rest = strings.TrimSpace(rest[end+1:])
+		if rest == "" {
+			break
+		}
+		if rest[0] != ',' {
+			return Compat{}, fmt.Errorf("invalid version range %q: expected ',' between intervals", spec)
+		}
+		rest = strings.TrimSpace(rest[1:])
+		if rest == "" {
+			return Compat{}, fmt.Errorf("invalid version range %q: trailing ','", spec)
+		}
+	}
+	return newCompat(MavenScheme, intervals...), nil
+}
+
+// parseFabricVersions converts the version requirement of a Fabric or Quilt
+// dependency. The value is either a single predicate string or an array of
+// alternatives, any of which satisfies the dependency.
+func parseFabricVersions(value any) (Compat, error) {
+	switch v := value.(type) {
+	case string:
+		return parseFabricPredicate(v)
+	case []any:
+		if len(v) == 0 {
+			return Compat{}, nil
+		}
+		result := Compat{none: true, scheme: SemVerScheme}
+		for _, alt := range v {
+			s, ok := alt.(string)
+			if !ok {
+				return Compat{}, fmt.Errorf("invalid version predicate %v", alt)
+			}
+			compat, err := parseFabricPredicate(s)
+			if err != nil {
+				return Compat{}, err
+			}
+			result = result.Union(compat)
+		}
+		return result, nil
+	default:
+		return Compat{}, fmt.Errorf("invalid version predicate %v", value)
+	}
+}
+
+// parseFabricPredicate parses a Fabric version predicate: space separated
+// terms that must all match, each being a bare version, a version prefixed by
+// one of =, ==, >=, >, <=, <, ~ or ^, a wildcard version like 1.20.x, or *.
+// Many mods separate terms with commas as well, which Fabric tolerates.
+func parseFabricPredicate(predicate string) (Compat, error) {
+	result := Compat{scheme: SemVerScheme}
+	terms := strings.FieldsFunc(predicate, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	for _, term := range terms {
+		compat, err := parseFabricTerm(term)
+		if err != nil {
+			return Compat{}, err
+		}
+		result = result.Intersect(compat)
+	}
+	return result, nil
+}
+
+// fabricOperators are ordered so that no operator is tried before one it is
+// a prefix of.
+var fabricOperators = []string{">=", "<=", "==", ">", "<", "=", "~", "^"}
+
+func parseFabricTerm(term string) (Compat, error) {
+	if term == "*" {
+		return Compat{scheme: SemVerScheme}, nil
+	}
+	var op string
+	for _, o := range fabricOperators {
+		if strings.HasPrefix(term, o) {
+			op = o
+			break
+		}
+	}
+	version := strings.TrimPrefix(term, op)
+	// == is accepted as an alias of =
+	if op == "==" {
+		op = "="
+	}
+	if version == "" || strings.ContainsFunc(version, invalidVersionRune) {
+		return Compat{}, fmt.Errorf("invalid version predicate %q", term)
+	}
+
+	core := version
+	if i := strings.IndexAny(core, "-+"); i >= 0 {
+		core = core[:i]
+	}
+	components := strings.Split(core, ".")
+	if wildcard := indexWildcard(components); wildcard >= 0 {
+		if op != "" && op != "=" {
+			return Compat{}, fmt.Errorf("invalid version predicate %q: wildcards only allow =", term)
+		}
+		for _, c := range components[wildcard:] {
+			if !isWildcard(c) {
+				return Compat{}, fmt.Errorf("invalid version predicate %q: wildcards must be trailing", term)
+			}
+		}
+		return prefixCompat(components[:wildcard])
+	}
+
+	switch op {
+	case ">=":
+		return newCompat(SemVerScheme, versionInterval{minVersion: version, includeMin: true}), nil
+	case ">":
+		return newCompat(SemVerScheme, versionInterval{minVersion: version}), nil
+	case "<=":
+		return newCompat(SemVerScheme, versionInterval{maxVersion: version, includeMax: true}), nil
+	case "<":
+		return newCompat(SemVerScheme, versionInterval{maxVersion: version}), nil
+	case "~", "^":
+		// ~ keeps the major and minor version, ^ only the major version
+		keep := util.If(op == "~", 2, 1)
+		if len(components) < keep {
+			components = append(components, "0")
+		}
+		upper, err := incrementVersion(components[:keep])
+		if err != nil {
+			return Compat{}, fmt.Errorf("invalid version predicate %q: %w", term, err)
+		}
+		return newCompat(SemVerScheme, versionInterval{
+			minVersion: version,
+			includeMin: true,
+			maxVersion: upper,
+		}), nil
+	default:
+		return newCompat(SemVerScheme, versionInterval{
+			minVersion: version,
+			maxVersion: version,
+			includeMin: true,
+			includeMax: true,
+		}), nil
+	}
+}
+
+// invalidVersionRune reports whether r cannot appear in a version, which
+// keeps the bounds of a range readable back from its label.
+func invalidVersionRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !strings.ContainsRune(".-+_*", r)
+}
+
+func isWildcard(component string) bool {
+	return component == "x" || component == "X" || component == "*"
+}
+
+func indexWildcard(components []string) int {
+	for i, c := range components {
+		if isWildcard(c) {
+			return i
+		}
+	}
+	return -1
+}
+
+// prefixCompat matches every version starting with the given components, so
+// that 1.20 matches [1.20, 1.21).
+func prefixCompat(components []string) (Compat, error) {
+	if len(components) == 0 {
+		return Compat{scheme: SemVerScheme}, nil
+	}
+	upper, err := incrementVersion(components)
+	if err != nil {
+		return Compat{}, err
+	}
+	return newCompat(SemVerScheme, versionInterval{
+		minVersion: strings.Join(components, "."),
+		includeMin: true,
+		maxVersion: upper,
+	}), nil
+}
+
+// incrementVersion returns the version following the given components, by
+// incrementing the last of them.
+func incrementVersion(components []string) (string, error) {
+	last, err := strconv.Atoi(components[len(components)-1])
+	if err != nil {
+		return "", fmt.Errorf("non-numeric version component %q", components[len(components)-1])
+	}
+	next := append(append([]string(nil), components[:len(components)-1]...), strconv.Itoa(last+1))
+	return strings.Join(next, "."), nil
+}
+
+// String writes the range in Maven syntax, so that it can be read back by
+// parseMavenRange. A range accepting every version is written as "".
+func (c *Compat) String() string {
+	if c.none {
+		return emptyRange
+	}
+	parts := make([]string, 0, len(c.intervals))
+	for _, iv := range c.intervals {
+		parts = append(parts, iv.String())
+	}
+	return strings.Join(parts, ",")
+}
+
+func (c *Compat) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Compat) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	return c.UnmarshalText([]byte(s))
+}
+
+// gobCompat is the form a Compat is stored in by the scan cache. Unlike its
+// JSON form, it keeps the version scheme and empty sets.
+type gobCompat struct {
+	Intervals []gobInterval
+	None      bool
+	Scheme    VersionScheme
+}
+
+type gobInterval struct {
+	Min, Max               string
+	IncludeMin, IncludeMax bool
+}
+
+func (c Compat) GobEncode() ([]byte, error) {
+	g := gobCompat{None: c.none, Scheme: c.scheme}
+	for _, iv := range c.intervals {
+		g.Intervals = append(g.Intervals, gobInterval{
+			Min:        iv.minVersion,
+			Max:        iv.maxVersion,
+			IncludeMin: iv.includeMin,
+			IncludeMax: iv.includeMax,
+		})
+	}
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(g)
+	return buf.Bytes(), err
+}
+
+func (c *Compat) GobDecode(data []byte) error {
+	var g gobCompat
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	*c = Compat{none: g.None, scheme: g.Scheme}
+	for _, iv := range g.Intervals {
+		c.intervals = append(c.intervals, versionInterval{
+			minVersion: iv.Min,
+			maxVersion: iv.Max,
+			includeMin: iv.IncludeMin,
+			includeMax: iv.IncludeMax,
+		})
+	}
+	return nil
+}
+
+// UnmarshalText parses a Maven version range, as written in mods.toml files.
+func (c *Compat) UnmarshalText(text []byte) error {
+	if c == nil {
+		return fmt.Errorf("compat: UnmarshalText on nil pointer")
+	}
+	compat, err := parseMavenRange(string(text))
+	if err != nil {
+		return err
+	}
+	*c = compat
+	return nil
+}
@@ -0,0 +1,201 @@
+package app
+
+import "testing"
+
+func mustParseMavenRange(t *testing.T, spec string) Compat {
+	t.Helper()
+	compat, err := parseMavenRange(spec)
+	if err != nil {
+		t.Fatalf("parseMavenRange(%q): %v", spec, err)
+	}
+	return compat
+}
+
+func TestParseMavenRangeRoundTrip(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"[1.0,2.0),[3.0,)", "[1.0, 2.0),[3.0,)"},
+		{"[1.2.3]", "[1.2.3]"},
+		{"(,1.0]", "(,1.0]"},
+		{"[1.0,)", "[1.0,)"},
+		{"(1.0,2.0]", "(1.0, 2.0]"},
+		{"1.0", ""},
+		{"*", ""},
+		{"", ""},
+		{"()", "()"},
+		// Overlapping and touching intervals are merged
+		{"[1.0,2.0),[1.5,3.0)", "[1.0, 3.0)"},
+		{"[1.0,2.0),[2.0,3.0)", "[1.0, 3.0)"},
+		{"[3.0,),[1.0,2.0)", "[1.0, 2.0),[3.0,)"},
+		// Empty intervals are dropped, leaving nothing
+		{"[2.0,1.0]", "()"},
+		{"(1.0,1.0]", "()"},
+	}
+	for _, tt := range tests {
+		compat := mustParseMavenRange(t, tt.spec)
+		if got := compat.String(); got != tt.want {
+			t.Errorf("parseMavenRange(%q).String() = %q, want %q", tt.spec, got, tt.want)
+		}
+		again := mustParseMavenRange(t, compat.String())
+		if got := again.String(); got != tt.want {
+			t.Errorf("parseMavenRange(%q) does not round trip: got %q, want %q", compat.String(), got, tt.want)
+		}
+		if again.IsNone() != compat.IsNone() || again.IsAny() != compat.IsAny() {
+			t.Errorf("parseMavenRange(%q) does not round trip emptiness", compat.String())
+		}
+	}
+}
+
+func TestParseMavenRangeErrors(t *testing.T) {
+	for _, spec := range []string{"[1.0", "[1.0,2.0),", "[1.0,2.0,3.0]", "(1.0)", "[]", "1.0,2.0", "[1.0,2.0)x"} {
+		if _, err := parseMavenRange(spec); err == nil {
+			t.Errorf("parseMavenRange(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestCompatContains(t *testing.T) {
+	tests := []struct {
+		spec    string
+		version string
+		want    bool
+	}{
+		{"[1.0,2.0),[3.0,)", "1.0", true},
+		{"[1.0,2.0),[3.0,)", "1.9.9", true},
+		{"[1.0,2.0),[3.0,)", "2.0", false},
+		{"[1.0,2.0),[3.0,)", "2.5", false},
+		{"[1.0,2.0),[3.0,)", "3.0", true},
+		{"[1.0,2.0),[3.0,)", "10.0", true},
+		{"[1.2.3]", "1.2.3", true},
+		{"[1.2.3]", "1.2.3.0", true},
+		{"[1.2.3]", "1.2.4", false},
+		{"(,1.0]", "0.9", true},
+		{"(,1.0]", "1.0", true},
+		{"(,1.0]", "1.0.1", false},
+		{"(,1.0)", "1.0-rc1", true},
+		{"()", "1.0", false},
+		{"", "1.0", true},
+	}
+	for _, tt := range tests {
+		compat := mustParseMavenRange(t, tt.spec)
+		if got := compat.Contains(tt.version); got != tt.want {
+			t.Errorf("parseMavenRange(%q).Contains(%q) = %v, want %v", tt.spec, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestCompatIntersect(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"[1.0,2.0)", "[1.5,3.0)", "[1.5, 2.0)"},
+		{"[1.0,2.0),[3.0,4.0)", "[1.5,3.5)", "[1.5, 2.0),[3.0, 3.5)"},
+		{"[1.0,2.0)", "[2.0,3.0)", "()"},
+		{"[1.0,2.0]", "[2.0,3.0)", "[2.0]"},
+		{"[1.0,2.0)", "", "[1.0, 2.0)"},
+		{"", "(,1.0]", "(,1.0]"},
+		{"()", "[1.0,)", "()"},
+	}
+	for _, tt := range tests {
+		a := mustParseMavenRange(t, tt.a)
+		b := mustParseMavenRange(t, tt.b)
+		got := a.Intersect(b)
+		if got.String() != tt.want {
+			t.Errorf("%q intersect %q = %q, want %q", tt.a, tt.b, got.String(), tt.want)
+		}
+		got = b.Intersect(a)
+		if got.String() != tt.want {
+			t.Errorf("%q intersect %q = %q, want %q", tt.b, tt.a, got.String(), tt.want)
+		}
+	}
+}
+
+func TestCompatUnion(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{"[1.0,2.0)", "[1.5,3.0)", "[1.0, 3.0)"},
+		{"[1.0,2.0)", "[3.0,)", "[1.0, 2.0),[3.0,)"},
+		{"[1.0,2.0)", "[2.0,3.0)", "[1.0, 3.0)"},
+		{"(,1.0)", "[1.0,)", ""},
+		{"[1.0,2.0)", "", ""},
+		{"()", "[1.0,)", "[1.0,)"},
+		{"()", "()", "()"},
+	}
+	for _, tt := range tests {
+		a := mustParseMavenRange(t, tt.a)
+		b := mustParseMavenRange(t, tt.b)
+		got := a.Union(b)
+		if got.String() != tt.want {
+			t.Errorf("%q union %q = %q, want %q", tt.a, tt.b, got.String(), tt.want)
+		}
+		got = b.Union(a)
+		if got.String() != tt.want {
+			t.Errorf("%q union %q = %q, want %q", tt.b, tt.a, got.String(), tt.want)
+		}
+	}
+}
+
+func TestParseFabricPredicate(t *testing.T) {
+	tests := []struct {
+		predicate string
+		version   string
+		want      bool
+	}{
+		{"=1.2.3", "1.2.3", true},
+		{"==1.2.3", "1.2.3", true},
+		{"==1.2.3", "1.2.4", false},
+		{"1.2.3", "1.2.3", true},
+		{">=1.2 <2", "1.9.9", true},
+		{">=1.2 <2", "2.0.0", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"1.20.x", "1.20.4", true},
+		{"1.20.x", "1.21", false},
+		{"==1.20.x", "1.20.4", true},
+		{"*", "0.0.1", true},
+		{">=1.0, <2.0", "1.5", true},
+		{">=1.0, <2.0", "2.0", false},
+		{">=1.0,<2.0", "0.9", false},
+	}
+	for _, tt := range tests {
+		compat, err := parseFabricPredicate(tt.predicate)
+		if err != nil {
+			t.Errorf("parseFabricPredicate(%q): %v", tt.predicate, err)
+			continue
+		}
+		if got := compat.Contains(tt.version); got != tt.want {
+			t.Errorf("parseFabricPredicate(%q).Contains(%q) = %v, want %v", tt.predicate, tt.version, got, tt.want)
+		}
+	}
+	for _, predicate := range []string{">=", ">1.x", "1.x.2", "~a.b", ">=1.0;", "<2.0)"} {
+		if _, err := parseFabricPredicate(predicate); err == nil {
+			t.Errorf("parseFabricPredicate(%q) succeeded, want an error", predicate)
+		}
+	}
+}
+
+func TestFabricPredicateLabelRoundTrip(t *testing.T) {
+	for _, predicate := range []string{">=1.0, <2.0", "~1.2.3", "1.20.x"} {
+		compat, err := parseFabricPredicate(predicate)
+		if err != nil {
+			t.Errorf("parseFabricPredicate(%q): %v", predicate, err)
+			continue
+		}
+		label := compat.String()
+		parsed, err := parseMavenRange(label)
+		if err != nil {
+			t.Errorf("label %q of %q does not parse: %v", label, predicate, err)
+			continue
+		}
+		if got := parsed.String(); got != label {
+			t.Errorf("label %q of %q reads back as %q", label, predicate, got)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// curseForgeManifest is the manifest.json file at the root of a CurseForge
+// modpack export
+type curseForgeManifest struct {
+	Minecraft struct {
+		Version    string `json:"version"`
+		ModLoaders []struct {
+			ID      string `json:"id"`
+			Primary bool   `json:"primary"`
+		} `json:"modLoaders"`
+	} `json:"minecraft"`
+	ManifestType string `json:"manifestType"`
+	Name         string `json:"name"`
+	Version      string `json:"version"`
+	Files        []struct {
+		ProjectID int  `json:"projectID"`
+		FileID    int  `json:"fileID"`
+		Required  bool `json:"required"`
+	} `json:"files"`
+	Overrides string `json:"overrides"`
+}
+
+// Scan a CurseForge modpack export. Jars bundled in the overrides folder are
+// read like the ones in a mods folder, while the project files listed in the
+// manifest become indexed nodes.
+func (s *scanner) scanCurseForgePack(ctx context.Context, packPath string) (*packContents, error) {
+	s.watch(packPath)
+	r, err := openPackArchive(packPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	var manifest *curseForgeManifest
+	for _, f := range r.File {
+		if f.Name != "manifest.json" {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		manifest = &curseForgeManifest{}
+		if err := json.Unmarshal(data, manifest); err != nil {
+			return nil, fmt.Errorf("invalid manifest.json: %w", err)
+		}
+		break
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("manifest.json not found in %s", packPath)
+	}
+	if manifest.ManifestType != "" && manifest.ManifestType != "minecraftModpack" {
+		return nil, fmt.Errorf("unsupported manifest type %q", manifest.ManifestType)
+	}
+
+	overrides := manifest.Overrides
+	if overrides == "" {
+		overrides = "overrides"
+	}
+	jars, _, err := s.readOverrideJars(ctx, r, []string{strings.TrimSuffix(overrides, "/") + "/"})
+	if err != nil {
+		return nil, err
+	}
+
+	var files []PackFile
+	for _, f := range manifest.Files {
+		files = append(files, PackFile{
+			ProjectID: strconv.Itoa(f.ProjectID),
+			FileID:    strconv.Itoa(f.FileID),
+			Required:  f.Required,
+		})
+	}
+	info := &PackInfo{
+		Name:             manifest.Name,
+		Version:          manifest.Version,
+		MinecraftVersion: manifest.Minecraft.Version,
+	}
+	for i, loader := range manifest.Minecraft.ModLoaders {
+		if !loader.Primary && i != len(manifest.Minecraft.ModLoaders)-1 {
+			continue
+		}
+		// Loader IDs look like "forge-47.2.0" or "fabric-0.15.3"
+		name, version, _ := strings.Cut(loader.ID, "-")
+		info.Loader = name
+		info.LoaderVersion = version
+		break
+	}
+	contents := readJars(jars)
+	contents.files = files
+	contents.info = info
+	return contents, nil
+}
@@ -1,8 +1,9 @@
 package app
 
 import (
+	"ModpackGraph/internal/util"
 	"archive/zip"
-	"bytes"
+	"context"
 	"embed"
 	_ "embed"
 	"encoding/base64"
@@ -10,9 +11,11 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/pelletier/go-toml/v2"
@@ -26,6 +29,7 @@ var ignoredMods = map[string]struct{}{
 	"forge":                        {},
 	"neoforge":                     {},
 	"fabricloader":                 {},
+	"quilt_loader":                 {},
 	"fabric-loader":                {},
 	"fabric":                       {},
 	"fabric-api":                   {},
@@ -44,15 +48,11 @@ var ignoredMods = map[string]struct{}{
 	"java":                         {},
 }
 
-func shouldIgnore(modid string, ignored map[string]struct{}) bool {
+func shouldIgnore(modid string) bool {
 	if modid == "" {
 		return true
 	}
 	_, ok := ignoredMods[strings.ToLower(modid)]
-	if ok {
-		return true
-	}
-	_, ok = ignored[modid]
 	return ok
 }
 
@@ -65,12 +65,12 @@ func loadDefaultIconData() {
 		_ = defaultIconFile.Close()
 		if err == nil {
 			defaultIconData = "data:image/png;base64," + base64.StdEncoding.EncodeToString(iconBytes)
-			//log.Info("Default icon data loaded")
+			slog.Debug("Default icon data loaded")
 		} else {
-			//log.WithError(err).Warn("Detected default icon")
+			slog.Warn("Failed to read default icon", "error", err)
 		}
 	} else {
-		//log.WithError(err).Warn("Detected default icon")
+		slog.Warn("Failed to open default icon", "error", err)
 	}
 }
 
@@ -81,22 +81,80 @@ type Mod struct {
 
 type ModMetadata struct {
 	Mod
-	Name     string `json:"name"`
-	Depends  []Dep  `json:"depends"`
+	Name     string   `json:"name"`
+	Depends  []Dep    `json:"depends"`
+	Provides []string `json:"provides,omitempty"`
+	// Platform holds dependencies on Minecraft, the mod loader or Java, which
+	// are checked against the target environment instead of other mods.
+	Platform []Dep  `json:"platform,omitempty"`
 	Path     string `json:"path"`
+	// IconData is the icon bundled with the mod, if any. The default icon is
+	// only applied to the graph, so that the scan cache does not store it.
 	IconData string `json:"iconData,omitempty"`
+	// warnings are problems that did not prevent reading the mod, such as a
+	// malformed version range, which is then read as accepting any version
+	warnings []error
+}
+
+// Relation describes how a mod relates to another mod it declares, using the
+// Fabric names for the kinds of relations.
+type Relation string
+
+const (
+	RelationRequires   Relation = "requires"
+	RelationRecommends Relation = "recommends"
+	RelationSuggests   Relation = "suggests"
+	// RelationBreaks means the game cannot run with both mods installed
+	RelationBreaks Relation = "breaks"
+	// RelationConflicts means both mods can run together, but misbehave
+	RelationConflicts Relation = "conflicts"
+	// RelationBundledBy links a mod to a mod whose jar bundles it. Unlike the
+	// other relations, it is not declared by either mod.
+	RelationBundledBy Relation = "bundled_by"
+)
+
+// Incompatible reports whether the relation declares that both mods should
+// not be installed together.
+func (r Relation) Incompatible() bool {
+	return r == RelationBreaks || r == RelationConflicts
 }
 
 type Dep struct {
-	ID            string `json:"id"`
-	Required      bool   `json:"required"`
-	Compatibility Compat `json:"compatibility,omitempty"`
+	ID            string   `json:"id"`
+	Required      bool     `json:"required"`
+	Relation      Relation `json:"relation,omitempty"`
+	Compatibility Compat   `json:"compatibility,omitempty"`
+	// AnyOf lists the IDs of a group of alternatives, this one included, any
+	// of which satisfies the dependency
+	AnyOf []string `json:"anyOf,omitempty"`
 }
 
-func getFabricMetadata(f *zip.File) (ModMetadata, error) {
+// relation returns the kind of relation, deriving it from Required for
+// extractors that only know about required and optional dependencies.
+func (d Dep) relation() Relation {
+	if d.Relation != "" {
+		return d.Relation
+	}
+	return util.If(d.Required, RelationRequires, RelationRecommends)
+}
+
+// Keys of the relation objects of fabric.mod.json
+var fabricRelations = []struct {
+	key      string
+	relation Relation
+}{
+	{"depends", RelationRequires},
+	{"recommends", RelationRecommends},
+	{"suggests", RelationSuggests},
+	{"breaks", RelationBreaks},
+	{"conflicts", RelationConflicts},
+}
+
+func getFabricMetadata(f *zip.File) (_ ModMetadata, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			//log.Errorf("Recovered in getFabricMetadata: %v", r)
+			slog.Error("Recovered in getFabricMetadata", "file", f.Name, "panic", r)
+			err = fmt.Errorf("malformed metadata: %v", r)
 		}
 	}()
 	rc, err := f.Open()
@@ -120,110 +178,291 @@ func getFabricMetadata(f *zip.File) (ModMetadata, error) {
 		name = modID
 	}
 	var depends []Dep
-	for _, key := range []string{"depends", "recommends", "suggests"} {
-		if val, ok := data[key].(map[string]any); ok {
-			required := key == "depends"
+	var warnings []error
+	for _, r := range fabricRelations {
+		if val, ok := data[r.key].(map[string]any); ok {
 			for k := range val {
-				v := fmt.Sprintf("%v", val[k])
-				var compat Compat
-				// parse version string. Format: "<=X.X.X", ">=X.X.X", "==X.X.X", "<X.X.X, >X.X.X"
-				if strings.Contains(v, ",") {
-					parts := strings.SplitN(v, ",", 2)
-					minPart := strings.TrimSpace(parts[0])
-					maxPart := strings.TrimSpace(parts[1])
-					if strings.HasPrefix(minPart, ">=") {
-						compat.minVersion = strings.TrimSpace(strings.TrimPrefix(minPart, ">="))
-						compat.includeMin = true
-					} else if strings.HasPrefix(minPart, ">") {
-						compat.minVersion = strings.TrimSpace(strings.TrimPrefix(minPart, ">"))
-						compat.includeMin = false
-					}
-					if strings.HasPrefix(maxPart, "<=") {
-						compat.maxVersion = strings.TrimSpace(strings.TrimPrefix(maxPart, "<="))
-						compat.includeMax = true
-					} else if strings.HasPrefix(maxPart, "<") {
-						compat.maxVersion = strings.TrimSpace(strings.TrimPrefix(maxPart, "<"))
-						compat.includeMax = false
-					}
-				} else {
-					if strings.HasPrefix(v, ">=") {
-						compat.minVersion = strings.TrimSpace(strings.TrimPrefix(v, ">="))
-						compat.includeMin = true
-					}
-					if strings.HasPrefix(v, ">") {
-						compat.minVersion = strings.TrimSpace(strings.TrimPrefix(v, ">"))
-						compat.includeMin = false
-					}
-					if strings.HasPrefix(v, "<=") {
-						compat.maxVersion = strings.TrimSpace(strings.TrimPrefix(v, "<="))
-						compat.includeMax = true
-					}
-					if strings.HasPrefix(v, "<") {
-						compat.maxVersion = strings.TrimSpace(strings.TrimPrefix(v, "<"))
-						compat.includeMax = false
-					}
-					if strings.HasPrefix(v, "==") {
-						ver := strings.TrimSpace(strings.TrimPrefix(v, "=="))
-						compat.minVersion = ver
-						compat.maxVersion = ver
-						compat.includeMin = true
-						compat.includeMax = true
-					}
+				compat, err := parseFabricVersions(val[k])
+				if err != nil {
+					// Keep the dependency without a version constraint
+					warnings = append(warnings, fmt.Errorf("%s %s of %s: %w", r.key, k, modID, err))
+					compat = Compat{}
 				}
 				depends = append(depends, Dep{
 					ID:            k,
 					Compatibility: compat,
-					Required:      required,
+					Required:      r.relation == RelationRequires,
+					Relation:      r.relation,
 				})
 			}
 		}
 	}
+	var provides []string
+	if val, ok := data["provides"].([]any); ok {
+		for _, p := range val {
+			if id, ok := p.(string); ok && id != "" {
+				provides = append(provides, id)
+			}
+		}
+	}
 
 	return ModMetadata{
 		Mod: Mod{
 			ID:      modID,
 			Version: version,
 		},
-		Name:    name,
-		Depends: depends,
+		Name:     name,
+		Depends:  depends,
+		Provides: provides,
+		warnings: warnings,
 	}, nil
 }
 
-func getForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
+func getQuiltMetadata(f *zip.File) (_ ModMetadata, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			//log.Errorf("Recovered in getForgeMetadata: %v", r)
+			slog.Error("Recovered in getQuiltMetadata", "file", f.Name, "panic", r)
+			err = fmt.Errorf("malformed metadata: %v", r)
 		}
 	}()
 	rc, err := f.Open()
 	if err != nil {
 		return ModMetadata{}, err
 	}
-	data, _ := io.ReadAll(rc)
+	var data struct {
+		QuiltLoader struct {
+			ID       string `json:"id"`
+			Version  string `json:"version"`
+			Metadata struct {
+				Name string `json:"name"`
+			} `json:"metadata"`
+			Depends  []any `json:"depends"`
+			Breaks   []any `json:"breaks"`
+			Provides []any `json:"provides"`
+		} `json:"quilt_loader"`
+	}
+	err = json.NewDecoder(rc).Decode(&data)
+	if err != nil {
+		return ModMetadata{}, err
+	}
 	err = rc.Close()
 	if err != nil {
 		return ModMetadata{}, err
 	}
 
+	loader := data.QuiltLoader
+	if loader.ID == "" {
+		return ModMetadata{}, fmt.Errorf("quilt_loader.id not found in quilt.mod.json")
+	}
+	name := loader.Metadata.Name
+	if name == "" {
+		name = loader.ID
+	}
+	var depends []Dep
+	var warnings []error
+	for _, d := range loader.Depends {
+		deps, errs := quiltDeps(d, RelationRequires)
+		depends = append(depends, deps...)
+		warnings = append(warnings, errs...)
+	}
+	for _, d := range loader.Breaks {
+		deps, errs := quiltDeps(d, RelationBreaks)
+		depends = append(depends, deps...)
+		warnings = append(warnings, errs...)
+	}
+	var provides []string
+	for _, p := range loader.Provides {
+		switch p := p.(type) {
+		case string:
+			provides = append(provides, quiltModID(p))
+		case map[string]any:
+			if id, ok := p["id"].(string); ok {
+				provides = append(provides, quiltModID(id))
+			}
+		}
+	}
+
+	return ModMetadata{
+		Mod: Mod{
+			ID:      loader.ID,
+			Version: loader.Version,
+		},
+		Name:     name,
+		Depends:  depends,
+		Provides: provides,
+		warnings: warnings,
+	}, nil
+}
+
+// quiltDeps converts a single entry of a Quilt "depends" or "breaks" array.
+// Entries are either a mod ID, an object with "id", "versions" and "optional",
+// or an array of alternatives, each of which is returned as its own Dep. The
+// alternatives of a dependency stay required, and record the group they are
+// part of so that installing any one of them satisfies the others. Malformed
+// version requirements are returned as errors, and read as accepting any
+// version.
+func quiltDeps(entry any, relation Relation) ([]Dep, []error) {
+	switch e := entry.(type) {
+	case string:
+		return []Dep{{
+			ID:       quiltModID(e),
+			Required: relation == RelationRequires,
+			Relation: relation,
+		}}, nil
+	case map[string]any:
+		id, _ := e["id"].(string)
+		if id == "" {
+			return nil, nil
+		}
+		if optional, _ := e["optional"].(bool); optional && relation == RelationRequires {
+			relation = RelationRecommends
+		}
+		var compat Compat
+		var errs []error
+		if versions, ok := e["versions"]; ok {
+			var err error
+			if compat, err = parseFabricVersions(versions); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", relation, id, err))
+				compat = Compat{}
+			}
+		}
+		return []Dep{{
+			ID:            quiltModID(id),
+			Required:      relation == RelationRequires,
+			Relation:      relation,
+			Compatibility: compat,
+		}}, errs
+	case []any:
+		var deps []Dep
+		var errs []error
+		for _, alt := range e {
+			altDeps, altErrs := quiltDeps(alt, relation)
+			deps = append(deps, altDeps...)
+			errs = append(errs, altErrs...)
+		}
+		if relation == RelationRequires && len(deps) > 1 {
+			group := make([]string, len(deps))
+			for i, dep := range deps {
+				group[i] = dep.ID
+			}
+			for i := range deps {
+				deps[i].AnyOf = group
+			}
+		}
+		return deps, errs
+	}
+	return nil, nil
+}
+
+// quiltModID strips the optional maven group from a Quilt "group:id" mod ID.
+func quiltModID(id string) string {
+	if i := strings.LastIndex(id, ":"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+func getForgeMetadata(r *zip.Reader, f *zip.File) ([]ModMetadata, error) {
+	return getModsTomlMetadata(r, f, forgeRelation)
+}
+
+func getNeoForgeMetadata(r *zip.Reader, f *zip.File) ([]ModMetadata, error) {
+	return getModsTomlMetadata(r, f, neoForgeRelation)
+}
+
+// forgeRelation reads the relation of a Forge mods.toml dependency, which only
+// distinguishes mandatory from optional dependencies. Forge still refuses to
+// load an optional dependency outside its version range, which is reported as
+// a version mismatch. The "ordering" field is not read, as it only decides
+// which mod loads first and not whether both can be installed together.
+func forgeRelation(dm map[string]any) (Relation, error) {
+	mandatory, _ := dm["mandatory"].(bool)
+	return util.If(mandatory, RelationRequires, RelationRecommends), nil
+}
+
+// neoForgeRelation reads the "type" field of a neoforge.mods.toml dependency.
+// Older NeoForge releases still used the Forge "mandatory" flag, which is
+// honoured when no type is given.
+func neoForgeRelation(dm map[string]any) (Relation, error) {
+	t, ok := dm["type"].(string)
+	if !ok {
+		if _, ok := dm["mandatory"]; ok {
+			return forgeRelation(dm)
+		}
+		return RelationRequires, nil
+	}
+	switch strings.ToLower(t) {
+	case "required":
+		return RelationRequires, nil
+	case "optional":
+		return RelationRecommends, nil
+	case "incompatible":
+		return RelationBreaks, nil
+	case "discouraged":
+		return RelationConflicts, nil
+	default:
+		return "", fmt.Errorf("unknown dependency type %q", t)
+	}
+}
+
+func getModsTomlMetadata(r *zip.Reader, f *zip.File, relationOf func(map[string]any) (Relation, error)) (_ []ModMetadata, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("Recovered in getModsTomlMetadata", "file", f.Name, "panic", r)
+			err = fmt.Errorf("malformed metadata: %v", r)
+		}
+	}()
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, _ := io.ReadAll(rc)
+	err = rc.Close()
+	if err != nil {
+		return nil, err
+	}
+
 	var tomlData map[string]any
 	if err := toml.Unmarshal(data, &tomlData); err != nil {
-		return ModMetadata{}, err
+		return nil, err
 	}
 	modsAny, ok := tomlData["mods"]
 	if !ok {
-		return ModMetadata{}, fmt.Errorf("no mods section found in mods.toml")
+		return nil, fmt.Errorf("no mods section found in %s", f.Name)
 	}
 	modsArr, ok := modsAny.([]any)
 	if !ok || len(modsArr) == 0 {
-		return ModMetadata{}, fmt.Errorf("no mod entries found in mods.toml")
+		return nil, fmt.Errorf("no mod entries found in %s", f.Name)
 	}
-	modEntry, ok := modsArr[0].(map[string]any)
-	if !ok {
-		return ModMetadata{}, fmt.Errorf("invalid mod entry in mods.toml")
+	// Jars bundling several mods declare one [[mods]] entry for each of them
+	var metas []ModMetadata
+	var firstErr error
+	for _, entry := range modsArr {
+		modEntry, ok := entry.(map[string]any)
+		if !ok {
+			firstErr = util.If(firstErr == nil, fmt.Errorf("invalid mod entry in %s", f.Name), firstErr)
+			continue
+		}
+		meta, err := getModsTomlEntry(r, f, tomlData, modEntry, relationOf)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		metas = append(metas, meta)
 	}
+	// The entries that could be read are returned along with the error, so
+	// that the others are diagnosed without hiding them
+	return metas, firstErr
+}
+
+// getModsTomlEntry reads a single [[mods]] entry of a mods.toml file, along
+// with its dependencies.
+func getModsTomlEntry(r *zip.Reader, f *zip.File, tomlData map[string]any, modEntry map[string]any, relationOf func(map[string]any) (Relation, error)) (ModMetadata, error) {
 	modID, ok := modEntry["modId"].(string)
 	if !ok {
-		return ModMetadata{}, fmt.Errorf("modId not found in mods.toml")
+		return ModMetadata{}, fmt.Errorf("modId not found in %s", f.Name)
 	}
 	version, ok := modEntry["version"].(string)
 	if !ok || version == "" {
@@ -258,24 +497,39 @@ func getForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
 		name = modID
 	}
 	var depends []Dep
+	var warnings []error
 	if deps, ok := tomlData["dependencies"].(map[string]any); ok {
 		modDepsAny, ok := deps[modID]
 		if ok {
 			if modDeps, ok := modDepsAny.([]any); ok {
 				for _, d := range modDeps {
-					dm := d.(map[string]any)
+					dm, ok := d.(map[string]any)
+					if !ok {
+						warnings = append(warnings, fmt.Errorf("dependency of %s is not a table", modID))
+						continue
+					}
 					depID, _ := dm["modId"].(string)
-					mandatory, _ := dm["mandatory"].(bool)
+					relation, err := relationOf(dm)
+					if err != nil {
+						// Like a malformed range, an unknown type should not
+						// hide the whole mod, so the dependency is kept as
+						// required
+						warnings = append(warnings, fmt.Errorf("dependency %s of %s: %w", depID, modID, err))
+						relation = RelationRequires
+					}
 					compatStr, _ := dm["versionRange"].(string)
 					var compat Compat
-					err := compat.UnmarshalText([]byte(compatStr))
-					if err != nil {
-						return ModMetadata{}, err
+					if err := compat.UnmarshalText([]byte(compatStr)); err != nil {
+						// A malformed range should not hide the whole mod, so
+						// the dependency is kept without a version constraint
+						warnings = append(warnings, fmt.Errorf("dependency %s of %s: %w", depID, modID, err))
+						compat = Compat{}
 					}
 					depends = append(depends, Dep{
 						ID:            depID,
 						Compatibility: compat,
-						Required:      mandatory,
+						Required:      relation == RelationRequires,
+						Relation:      relation,
 					})
 				}
 			}
@@ -353,11 +607,6 @@ func getForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
 			}
 		}
 	}
-	if iconData == "" {
-		// Use default icon
-		iconData = defaultIconData
-	}
-
 	return ModMetadata{
 		Mod: Mod{
 			ID:      modID,
@@ -366,13 +615,15 @@ func getForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
 		Name:     name,
 		Depends:  depends,
 		IconData: iconData,
+		warnings: warnings,
 	}, nil
 }
 
-func getOldForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
+func getOldForgeMetadata(r *zip.Reader, f *zip.File) (_ ModMetadata, err error) {
 	defer func() {
 		if r := recover(); r != nil {
-			//log.Errorf("Recovered in getOldForgeMetadata: %v", r)
+			slog.Error("Recovered in getOldForgeMetadata", "file", f.Name, "panic", r)
+			err = fmt.Errorf("malformed metadata: %v", r)
 		}
 	}()
 	rc, err := f.Open()
@@ -468,146 +719,28 @@ func getOldForgeMetadata(r *zip.Reader, f *zip.File) (ModMetadata, error) {
 	}, nil
 }
 
-type Compat struct {
-	minVersion string
-	maxVersion string
-	includeMin bool
-	includeMax bool
-}
-
-func (c *Compat) Intersect(other Compat) Compat {
-	var result Compat
-	// Determine min version
-	if c.minVersion == "" {
-		result.minVersion = other.minVersion
-		result.includeMin = other.includeMin
-	} else if other.minVersion == "" {
-		result.minVersion = c.minVersion
-		result.includeMin = c.includeMin
-	} else {
-		if c.minVersion > other.minVersion {
-			result.minVersion = c.minVersion
-			result.includeMin = c.includeMin
-		} else if c.minVersion < other.minVersion {
-			result.minVersion = other.minVersion
-			result.includeMin = other.includeMin
-		} else {
-			result.minVersion = c.minVersion
-			result.includeMin = c.includeMin && other.includeMin
-		}
-	}
-	// Determine max version
-	if c.maxVersion == "" {
-		result.maxVersion = other.maxVersion
-		result.includeMax = other.includeMax
-	} else if other.maxVersion == "" {
-		result.maxVersion = c.maxVersion
-		result.includeMax = c.includeMax
-	} else {
-		if c.maxVersion < other.maxVersion {
-			result.maxVersion = c.maxVersion
-			result.includeMax = c.includeMax
-		} else if c.maxVersion > other.maxVersion {
-			result.maxVersion = other.maxVersion
-			result.includeMax = other.includeMax
-		} else {
-			result.maxVersion = c.maxVersion
-			result.includeMax = c.includeMax && other.includeMax
-		}
-	}
-	return result
-}
-
-func (c *Compat) rangeToCompat(compat string) Compat {
-	if compat == "" {
-		return Compat{}
-	}
-	var left, a, b, right string
-	if strings.HasPrefix(compat, "[") || strings.HasPrefix(compat, "(") {
-		left = string(compat[0])
-		compat = compat[1:]
-	}
-	if strings.HasSuffix(compat, "]") || strings.HasSuffix(compat, ")") {
-		right = string(compat[len(compat)-1])
-		compat = compat[:len(compat)-1]
-	}
-	// now split by comma
-	parts := strings.SplitN(compat, ",", 2)
-	if len(parts) == 2 {
-		a = strings.TrimSpace(parts[0])
-		b = strings.TrimSpace(parts[1])
-	} else {
-		a = strings.TrimSpace(parts[0])
-	}
-	var result Compat
-	if a != "" {
-		result.minVersion = a
-		if left == "[" {
-			result.includeMin = true
-		}
-	}
-	if b != "" {
-		result.maxVersion = b
-		if right == "]" {
-			result.includeMax = true
-		}
-	}
-	return result
-}
-
-func (c *Compat) String() string {
-	if c.minVersion == "" && c.maxVersion == "" {
-		return ""
-	}
-	var left, right string
-	if c.includeMin {
-		left = "["
-	} else {
-		left = "("
-	}
-	if c.includeMax {
-		right = "]"
-	} else {
-		right = ")"
-	}
-	if c.minVersion != "" && c.maxVersion != "" {
-		return fmt.Sprintf("%s%s, %s%s", left, c.minVersion, c.maxVersion, right)
-	} else if c.minVersion != "" {
-		return fmt.Sprintf("%s%s,%s", left, c.minVersion, right)
-	} else {
-		return fmt.Sprintf("%s,%s%s", left, c.maxVersion, right)
-	}
-}
-
-func (c *Compat) MarshalJSON() ([]byte, error) {
-	return json.Marshal(c.String())
-}
-
-func (c *Compat) UnmarshalJSON(data []byte) error {
-	return c.UnmarshalText(data)
-}
-
-func (c *Compat) UnmarshalText(text []byte) error {
-	if c == nil {
-		return fmt.Errorf("compat: UnmarshalText on nil pointer")
-	}
-	*c = c.rangeToCompat(string(text))
-	return nil
-}
-
-// Extract metadata from a jar path
-func extractModMetadata(path string, r *zip.Reader) (ModMetadata, error) {
-	var err error
-	var meta ModMetadata
+// Extract metadata from a jar path. A jar may contain several mods. The first
+// metadata file that cannot be parsed is reported along with the mods read
+// from the others.
+func extractModMetadata(path string, r *zip.Reader) ([]ModMetadata, error) {
+	var parseErr error
+	var metas []ModMetadata
 	for _, f := range r.File {
-		err = nil
+		var err error
+		var meta ModMetadata
 		switch f.Name {
 		// Fabric
 		case "fabric.mod.json":
 			meta, err = getFabricMetadata(f)
+		// Quilt
+		case "quilt.mod.json":
+			meta, err = getQuiltMetadata(f)
 		// Forge modern
 		case "META-INF/mods.toml":
-			meta, err = getForgeMetadata(r, f)
+			metas, err = getForgeMetadata(r, f)
+		// NeoForge
+		case "META-INF/neoforge.mods.toml":
+			metas, err = getNeoForgeMetadata(r, f)
 		// Forge old mcmod.info
 		case "mcmod.info":
 			meta, err = getOldForgeMetadata(r, f)
@@ -616,142 +749,281 @@ func extractModMetadata(path string, r *zip.Reader) (ModMetadata, error) {
 		}
 
 		if err != nil {
-			//log.WithError(err).Error("Error extracting metadata from %s", f.Name)
-			continue
-		} else {
-			break
+			parseErr = util.If(parseErr == nil, fmt.Errorf("%s: %w", f.Name, err), parseErr)
+			// Some entries of a mods.toml may have been read all the same
+			if len(metas) == 0 {
+				continue
+			}
+		}
+		if metas == nil {
+			metas = []ModMetadata{meta}
 		}
+		break
 	}
-	meta.Path = path
-	return meta, err
+	for i := range metas {
+		metas[i].Path = path
+	}
+	return metas, parseErr
 }
 
-func getModJarsFromBytes(name string, jarBytes []byte) map[string]*zip.Reader {
-	r, err := zip.NewReader(bytes.NewReader(jarBytes), int64(len(jarBytes)))
+// getModJarsAt reads a jar of the given size along with every jar bundled in
+// it, however deeply nested. Only the entries holding metadata and bundled
+// jars are read from the archive.
+func getModJarsAt(name string, archive io.ReaderAt, size int64) (map[string]*modJar, error) {
+	r, err := zip.NewReader(archive, size)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	// Collect embedded jars
-	var jars map[string]*zip.Reader
-	for _, f := range r.File {
-		if strings.HasSuffix(f.Name, ".jar") {
-			rc, err := f.Open()
-			if err != nil {
-				//log.WithError(err).Error("Error opening jar file")
-				continue
-			}
-			data, err := io.ReadAll(rc)
-			_ = rc.Close()
-			if err != nil {
-				//log.WithError(err).Error("Error reading jar file")
-				continue
-			}
-			embeddedJars := getModJarsFromBytes(f.Name, data)
-			for k, v := range embeddedJars {
-				if jars == nil {
-					jars = make(map[string]*zip.Reader)
-				}
-				jars[k] = v
-			}
-		}
+	file, err := hashFile(name, size, io.NewSectionReader(archive, 0, size))
+	if err != nil {
+		return nil, err
 	}
-	if jars == nil {
-		jars = make(map[string]*zip.Reader)
+	return readJar(file, archive, r), nil
+}
+
+// getModJars reads a jar from disk along with every jar bundled in it.
+func getModJars(jarPath string) (map[string]*modJar, error) {
+	f, err := os.Open(jarPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return getModJarsAt(jarPath, f, stat.Size())
+}
+
+// readJar reads the metadata of an open jar, then that of the jars bundled in
+// it. Bundled jars are opened one at a time and released as soon as their
+// own metadata and bundled jars have been read. Bundled jars that cannot be
+// read are diagnosed on the jar bundling them.
+func readJar(file ModFile, archive io.ReaderAt, r *zip.Reader) map[string]*modJar {
+	jar := &modJar{ModFile: file}
+	jar.metas = readJarMetadata(jar, r)
+	jars := map[string]*modJar{jar.Path: jar}
+	for _, f := range nestedJarEntries(r) {
+		nestedPath := nestedJarPath(jar.Path, f.Name)
+		nested, err := readZipEntryJars(nestedPath, archive, f)
+		if err != nil {
+			jar.diagnose(nestedPath, StageNested, SeverityError, err)
+			continue
+		}
+		nested[nestedPath].NestedIn = jar.Path
+		jar.Bundles = append(jar.Bundles, nestedPath)
+		for k, v := range nested {
+			jars[k] = v
+		}
 	}
-	jars[name] = r
 	return jars
 }
 
-func getModJars(jarPath string) map[string]*zip.Reader {
-	data, err := os.ReadFile(jarPath)
+// readZipEntryJars reads a jar stored in archive along with every jar bundled
+// in it.
+func readZipEntryJars(name string, archive io.ReaderAt, f *zip.File) (map[string]*modJar, error) {
+	entry, release, err := openZipEntry(archive, f)
 	if err != nil {
-		return nil
+		return nil, err
 	}
-	return getModJarsFromBytes(jarPath, data)
+	defer release()
+	return getModJarsAt(name, entry, int64(f.UncompressedSize64))
 }
 
 // Scan folder
-func scanModFolder(folder string) (*Graph, error) {
-	var jars map[string]*zip.Reader
+func (s *scanner) scanModFolder(ctx context.Context, folder string) (*packContents, error) {
+	jars, err := s.findFolderJars(ctx, folder)
+	if err != nil {
+		return nil, err
+	}
+	return readJars(jars), nil
+}
+
+// readJars reads the mods declared by the given jars.
+func readJars(jars map[string]*modJar) *packContents {
+	mods, instances := extractMods(jars)
+	var diagnostics []Diagnostic
+	for _, jar := range jars {
+		diagnostics = append(diagnostics, jar.diagnostics...)
+	}
+	return &packContents{
+		mods:        mods,
+		jars:        modFiles(jars),
+		duplicates:  findDuplicates(jars, instances),
+		diagnostics: diagnostics,
+	}
+}
+
+// findFolderJars reads every jar under folder, along with the jars nested in
+// them. Jars left unchanged since a previous scan are read from the scan
+// cache.
+func (s *scanner) findFolderJars(ctx context.Context, folder string) (map[string]*modJar, error) {
+	s.watch(folder)
+	var paths []string
 	err := filepath.WalkDir(folder, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
 		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".jar") {
 			return nil
 		}
-		modJars := getModJars(path)
-		for p, r := range modJars {
-			if jars == nil {
-				jars = make(map[string]*zip.Reader)
-			}
-			jars[p] = r
-		}
+		paths = append(paths, path)
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
-	//log.Debugf("Found %d jars", len(jars))
-	ignored := make(map[string]struct{})
-	mods := make(map[string]ModMetadata)
-	for jarPath, r := range jars {
-		info, err := extractModMetadata(jarPath, r)
-		if err != nil {
-			//log.WithError(err).WithField("path", jarPath).Error("Error extracting mod metadata")
-			continue
+	slog.Debug("Found mods folder jars", "folder", folder, "jars", len(paths))
+	return openJars(ctx, s, paths, func(p string) string {
+		return p
+	}, s.cache.getModJars)
+}
+
+// readJarMetadata reads the mods declared by a jar and records their IDs on
+// it. Dependencies on the game, the loader or Java are moved to the platform
+// requirements of each mod.
+func readJarMetadata(jar *modJar, r *zip.Reader) []ModMetadata {
+	infos, err := extractModMetadata(jar.Path, r)
+	if err != nil {
+		// The jar is only missing from the graph if no other metadata file
+		// could be read
+		jar.diagnose(jar.Path, StageParse, util.If(len(infos) == 0, SeverityError, SeverityWarning), err)
+		if len(infos) == 0 {
+			return nil
 		}
-		if strings.HasPrefix(info.Path, "META-INF") {
-			ignored[info.ID] = struct{}{}
-			continue
+	}
+	if len(infos) == 0 {
+		// Libraries bundled in mods are expected to have no metadata
+		severity := util.If(strings.Contains(jar.Path, "!/"), SeverityInfo, SeverityWarning)
+		jar.diagnose(jar.Path, StageMetadata, severity, errNoMetadata)
+		return nil
+	}
+	var metas []ModMetadata
+	for _, info := range infos {
+		for _, warning := range info.warnings {
+			jar.diagnose(jar.Path, StageParse, SeverityWarning, warning)
 		}
-		if shouldIgnore(info.ID, ignored) {
+		info.warnings = nil
+		if shouldIgnore(info.ID) {
 			continue
 		}
+		jar.Mods = append(jar.Mods, info.ID)
 		var filtered []Dep
 		for _, dep := range info.Depends {
-			if shouldIgnore(dep.ID, ignored) {
+			if _, ok := platformIDs[strings.ToLower(dep.ID)]; ok {
+				info.Platform = append(info.Platform, dep)
+				continue
+			}
+			if shouldIgnore(dep.ID) {
 				continue
 			}
 			filtered = append(filtered, dep)
 		}
 		info.Depends = filtered
-		mods[info.ID] = info
+		metas = append(metas, info)
 	}
-	//log.Debugf("Extracted metadata for %d mods", len(mods))
-	// Filter embedded mods from dependencies
-	for k, mod := range mods {
-		var filtered []Dep
-		for _, dep := range mod.Depends {
+	return metas
+}
 
-			if depMod, exists := mods[dep.ID]; (exists && strings.HasPrefix(depMod.Path, "META-INF")) || shouldIgnore(dep.ID, ignored) {
+// extractMods merges the metadata read from every jar. A single instance of
+// each mod ends up in the returned mods, but every instance read is kept,
+// keyed by mod ID.
+func extractMods(jars map[string]*modJar) (map[string]ModMetadata, map[string][]ModMetadata) {
+	slog.Debug("Extracting mods", "jars", len(jars))
+	paths := make([]string, 0, len(jars))
+	for p := range jars {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	mods := make(map[string]ModMetadata)
+	instances := make(map[string][]ModMetadata)
+	for _, p := range paths {
+		jar := jars[p]
+		for _, info := range jar.metas {
+			instances[info.ID] = append(instances[info.ID], info)
+			// A mod found in several jars is read from the one installed on
+			// its own, with the highest version
+			if existing, ok := mods[info.ID]; ok && !preferInstance(info, jar, existing, jars[existing.Path]) {
 				continue
 			}
-			filtered = append(filtered, dep)
+			mods[info.ID] = info
 		}
-		mod.Depends = filtered
-		mods[k] = mod
 	}
-	//log.Debugf("Found %d mods", len(mods))
-	return generateDependencyGraph(mods)
+	slog.Debug("Extracted mods", "mods", len(mods))
+	return mods, instances
 }
 
-func generateDependencyGraph(mods map[string]ModMetadata) (*Graph, error) {
+func generateDependencyGraph(mods map[string]ModMetadata, jars []ModFile, files []PackFile) (*Graph, error) {
 	graph := NewGraph()
-	embeddings := make(map[string]struct{})
 	nodes := make(map[string]*Node)
 	for _, mod := range mods {
 		node := graph.AddNode(Node{
 			ID:             mod.ID,
 			Label:          mod.Name,
-			Icon:           mod.IconData,
+			Icon:           util.If(mod.IconData != "", mod.IconData, defaultIconData),
 			Present:        true,
 			PresentVersion: mod.Version,
+			Path:           mod.Path,
 		})
-		if strings.HasPrefix("META-INF", mod.Path) {
-			embeddings[mod.ID] = struct{}{}
-		}
 		nodes[mod.ID] = node
 	}
+	for _, file := range files {
+		label := file.Name
+		if label == "" && file.Path != "" {
+			label = path.Base(file.Path)
+		} else if label == "" {
+			label = fmt.Sprintf("CurseForge project %s", file.ProjectID)
+		}
+		graph.AddNode(Node{
+			ID:        file.nodeID(),
+			Label:     label,
+			Icon:      defaultIconData,
+			Present:   file.Required,
+			Indexed:   true,
+			Optional:  !file.Required,
+			Path:      file.Path,
+			ProjectID: file.ProjectID,
+			FileID:    file.FileID,
+			Hashes:    file.Hashes,
+			Env:       file.Env,
+		})
+	}
+	aliases := resolveAliases(mods)
+	for alias, id := range aliases {
+		nodes[id].Aliases = append(nodes[id].Aliases, alias)
+	}
+	for _, node := range nodes {
+		sort.Strings(node.Aliases)
+	}
 	for _, mod := range mods {
 		for _, dep := range mod.Depends {
+			// Dependencies on a provided ID resolve to the providing mod
+			if id, ok := aliases[dep.ID]; ok {
+				dep.ID = id
+			}
+			relation := dep.relation()
+			if relation == RelationRequires && satisfiedByAlternative(dep, mods, aliases) {
+				// Another mod of the group is installed, so this one is only
+				// one of the choices
+				relation = RelationRecommends
+				dep.Required = false
+			}
+			if relation.Incompatible() {
+				// Incompatibilities only matter when the other mod is installed
+				// in one of the versions they name
+				if _, installed := mods[dep.ID]; installed && isIncompatible(nodes[dep.ID], dep.Compatibility) {
+					graph.AddEdgeFromIDs(Edge{
+						Source:   mod.ID,
+						Target:   dep.ID,
+						Relation: relation,
+						Label:    dep.Compatibility.String(),
+						Status:   StatusConflict,
+					})
+				}
+				continue
+			}
 			depNode, exists := nodes[dep.ID]
 			if !exists {
 				depNode = graph.AddNode(Node{
@@ -768,9 +1040,111 @@ func generateDependencyGraph(mods map[string]ModMetadata) (*Graph, error) {
 				Source:   mod.ID,
 				Target:   dep.ID,
 				Required: dep.Required,
+				Relation: relation,
 				Label:    dep.Compatibility.String(),
+				Status:   dependencyStatus(depNode, dep.Compatibility),
 			})
 		}
 	}
+	addBundledEdges(graph, jars)
+	// The mods of indexed files are unknown, so a mod that no jar declares may
+	// still be one of them
+	absent := util.If(len(files) > 0, StatusUnresolved, StatusMissing)
+	for _, node := range graph.Nodes {
+		node.Status = StatusOK
+		if !node.Present && !node.Optional {
+			node.Status = absent
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.Status == StatusMissing {
+			edge.Status = absent
+		}
+	}
+	for _, edge := range graph.Edges {
+		if edge.Status == StatusVersionMismatch {
+			graph.Nodes[edge.Target].Status = StatusVersionMismatch
+		}
+	}
+	// Both mods of an incompatible pair are flagged, unless a worse problem
+	// was already found for them
+	for _, edge := range graph.Edges {
+		if edge.Status != StatusConflict {
+			continue
+		}
+		for _, id := range []string{edge.Source, edge.Target} {
+			if node := graph.Nodes[id]; node.Status == StatusOK {
+				node.Status = StatusConflict
+			}
+		}
+	}
 	return graph, nil
 }
+
+// isIncompatible reports whether an installed node falls within the versions
+// another mod declared itself incompatible with. A node whose version is
+// unknown is only incompatible when every version is.
+func isIncompatible(node *Node, compat Compat) bool {
+	if !isKnownVersion(node.PresentVersion) {
+		return compat.IsAny()
+	}
+	return compat.Contains(node.PresentVersion)
+}
+
+// satisfiedByAlternative reports whether a dependency that is not installed
+// itself is satisfied by another mod of its group of alternatives.
+func satisfiedByAlternative(dep Dep, mods map[string]ModMetadata, aliases map[string]string) bool {
+	installed := func(id string) bool {
+		if alias, ok := aliases[id]; ok {
+			id = alias
+		}
+		_, ok := mods[id]
+		return ok
+	}
+	if len(dep.AnyOf) == 0 || installed(dep.ID) {
+		return false
+	}
+	for _, id := range dep.AnyOf {
+		if installed(id) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAliases maps every mod ID provided by a mod, but not used by an
+// installed mod of its own, to the ID of the providing mod. When several mods
+// provide the same ID, the lowest mod ID wins so that the result is stable.
+func resolveAliases(mods map[string]ModMetadata) map[string]string {
+	aliases := make(map[string]string)
+	for _, mod := range mods {
+		for _, alias := range mod.Provides {
+			if _, real := mods[alias]; real || alias == mod.ID {
+				continue
+			}
+			if existing, ok := aliases[alias]; ok && existing < mod.ID {
+				continue
+			}
+			aliases[alias] = mod.ID
+		}
+	}
+	return aliases
+}
+
+// dependencyStatus evaluates whether node satisfies a dependency on it
+// constrained by compat.
+func dependencyStatus(node *Node, compat Compat) Status {
+	if !node.Present {
+		return StatusMissing
+	}
+	if !isKnownVersion(node.PresentVersion) || compat.Contains(node.PresentVersion) {
+		return StatusOK
+	}
+	return StatusVersionMismatch
+}
+
+// isKnownVersion reports whether a mod version was actually declared, as
+// opposed to missing or left as an unexpanded build placeholder.
+func isKnownVersion(version string) bool {
+	return version != "" && version != "<not specified>" && !strings.HasPrefix(version, "${")
+}
@@ -0,0 +1,399 @@
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestIncompatibleVersionRange(t *testing.T) {
+	incompatible := func(spec string) Dep {
+		compat, err := parseMavenRange(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Dep{ID: "other", Relation: RelationBreaks, Compatibility: compat}
+	}
+	requires := func(spec string) Dep {
+		compat, err := parseMavenRange(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return Dep{ID: "other", Required: true, Relation: RelationRequires, Compatibility: compat}
+	}
+	tests := []struct {
+		name    string
+		deps    []Dep
+		version string
+		want    bool
+	}{
+		{"in range", []Dep{incompatible("[1.0,2.0)")}, "1.5", true},
+		{"out of range", []Dep{incompatible("[1.0,2.0)")}, "2.1", false},
+		{"any version", []Dep{incompatible("")}, "2.1", true},
+		{"unknown version in range", []Dep{incompatible("[1.0,2.0)")}, "${file.jarVersion}", false},
+		{"unknown version, any version", []Dep{incompatible("")}, "${file.jarVersion}", true},
+		{"also required", []Dep{requires("[1.0,)"), incompatible("(,1.2)")}, "1.1", true},
+		{"also required, out of range", []Dep{requires("[1.0,)"), incompatible("(,1.2)")}, "1.3", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mods := map[string]ModMetadata{
+				"mod":   {Mod: Mod{ID: "mod", Version: "1.0"}, Depends: tt.deps},
+				"other": {Mod: Mod{ID: "other", Version: tt.version}},
+			}
+			graph, err := generateDependencyGraph(mods, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			flagged := false
+			for _, edge := range graph.SortedEdges() {
+				flagged = flagged || (edge.Source == "mod" && edge.Target == "other" && edge.Relation.Incompatible())
+			}
+			if flagged != tt.want {
+				t.Errorf("edge to other %s = %v, want %v", tt.version, flagged, tt.want)
+			}
+			node, _ := graph.GetNode("other")
+			if got := node.Status == StatusConflict; got != tt.want {
+				t.Errorf("other flagged as conflicting = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuiltAnyOfDependency(t *testing.T) {
+	entry := []any{"a", map[string]any{"id": "org:b", "versions": ">=1.0"}}
+	deps, errs := quiltDeps(entry, RelationRequires)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(deps) != 2 {
+		t.Fatalf("quiltDeps returned %d deps, want 2", len(deps))
+	}
+	for _, dep := range deps {
+		if dep.Relation != RelationRequires || !dep.Required {
+			t.Errorf("alternative %s is %s, want it required", dep.ID, dep.Relation)
+		}
+		if len(dep.AnyOf) != 2 || dep.AnyOf[0] != "a" || dep.AnyOf[1] != "b" {
+			t.Errorf("alternative %s has group %v, want [a b]", dep.ID, dep.AnyOf)
+		}
+	}
+	if deps[1].Compatibility.Contains("0.9") || !deps[1].Compatibility.Contains("1.2") {
+		t.Errorf("versions of b read as %q, want >=1.0", deps[1].Compatibility.String())
+	}
+
+	tests := []struct {
+		name      string
+		installed []string
+		want      map[string]bool
+	}{
+		// With no alternative installed, every one of them is required
+		{"none installed", nil, map[string]bool{"a": true, "b": true}},
+		{"one installed", []string{"b"}, map[string]bool{"a": false, "b": true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mods := map[string]ModMetadata{
+				"mod": {Mod: Mod{ID: "mod", Version: "1.0"}, Depends: deps},
+			}
+			for _, id := range tt.installed {
+				mods[id] = ModMetadata{Mod: Mod{ID: id, Version: "1.0"}}
+			}
+			graph, err := generateDependencyGraph(mods, nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, edge := range graph.SortedEdges() {
+				if want, ok := tt.want[edge.Target]; ok && edge.Required != want {
+					t.Errorf("dependency on %s required = %v, want %v", edge.Target, edge.Required, want)
+				}
+			}
+		})
+	}
+}
+
+// zipJar builds a jar holding the given files.
+func zipJar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMalformedVersionRangeIsDiagnosed(t *testing.T) {
+	jar := zipJar(t, map[string]string{
+		"fabric.mod.json": `{"id": "mod", "version": "1.0", "depends": {"other": ">=1.x"}}`,
+	})
+	jars, err := getModJarsAt("mod.jar", bytes.NewReader(jar), int64(len(jar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := jars["mod.jar"]
+	if len(read.metas) != 1 || len(read.metas[0].Depends) != 1 {
+		t.Fatalf("read %+v, want one mod with one dependency", read.metas)
+	}
+	if len(read.diagnostics) != 1 {
+		t.Fatalf("got diagnostics %+v, want one", read.diagnostics)
+	}
+	if d := read.diagnostics[0]; d.Stage != StageParse || d.Severity != SeverityWarning {
+		t.Errorf("got diagnostic %+v, want a parse warning", d)
+	}
+}
+
+func TestIndexedFilesLeaveDependenciesUnresolved(t *testing.T) {
+	mods := map[string]ModMetadata{
+		"mod": {Mod: Mod{ID: "mod", Version: "1.0"}, Depends: []Dep{{ID: "other", Required: true}}},
+	}
+	tests := []struct {
+		name  string
+		files []PackFile
+		want  Status
+	}{
+		{"no indexed files", nil, StatusMissing},
+		{"indexed files", []PackFile{{Path: "mods/other-1.0.jar"}}, StatusUnresolved},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := generateDependencyGraph(mods, nil, tt.files)
+			if err != nil {
+				t.Fatal(err)
+			}
+			node, _ := graph.GetNode("other")
+			if node.Status != tt.want {
+				t.Errorf("other has status %s, want %s", node.Status, tt.want)
+			}
+			for _, edge := range graph.SortedEdges() {
+				if edge.Target == "other" && edge.Status != tt.want {
+					t.Errorf("dependency on other has status %s, want %s", edge.Status, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestOptionalManifestFiles(t *testing.T) {
+	files := []PackFile{
+		{ProjectID: "1", FileID: "10", Required: true},
+		{ProjectID: "2", FileID: "20"},
+	}
+	graph, err := generateDependencyGraph(map[string]ModMetadata{}, nil, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	required, _ := graph.GetNode("curseforge:1:10")
+	if !required.Present || required.Optional || required.Status != StatusOK {
+		t.Errorf("required file read as %+v, want present", *required)
+	}
+	optional, _ := graph.GetNode("curseforge:2:20")
+	if optional.Present || !optional.Optional || optional.Status != StatusOK {
+		t.Errorf("optional file read as %+v, want optional and not present", *optional)
+	}
+}
+
+func TestNestedJarsAreReadInPlace(t *testing.T) {
+	inner := func(id string) string {
+		return string(zipJar(t, map[string]string{
+			"fabric.mod.json": `{"id": "` + id + `", "version": "1.0"}`,
+		}))
+	}
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, entry := range []struct {
+		name    string
+		method  uint16
+		content string
+	}{
+		{"fabric.mod.json", zip.Deflate, `{"id": "outer", "version": "1.0"}`},
+		{"META-INF/jars/deflated.jar", zip.Deflate, inner("deflated")},
+		{"META-INF/jars/stored.jar", zip.Store, inner("stored")},
+	} {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: entry.name, Method: entry.method})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	jars, err := getModJarsAt("outer.jar", bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []string{"deflated", "stored"} {
+		jar, ok := jars["outer.jar!/META-INF/jars/"+id+".jar"]
+		if !ok || len(jar.metas) != 1 || jar.metas[0].ID != id || jar.NestedIn != "outer.jar" {
+			t.Errorf("bundled jar %s read as %+v", id, jar)
+		}
+	}
+}
+
+func TestMalformedModsTomlDependencyIsDiagnosed(t *testing.T) {
+	jar := zipJar(t, map[string]string{
+		"META-INF/mods.toml": `modLoader = "javafml"
+loaderVersion = "[47,)"
+[[mods]]
+modId = "mod"
+version = "1.0"
+[dependencies]
+mod = ["oops", { modId = "other", mandatory = true, versionRange = "[1,)" }]
+`,
+	})
+	jars, err := getModJarsAt("mod.jar", bytes.NewReader(jar), int64(len(jar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := jars["mod.jar"]
+	if len(read.metas) != 1 || len(read.metas[0].Depends) != 1 || read.metas[0].Depends[0].ID != "other" {
+		t.Fatalf("read %+v, want mod depending on other", read.metas)
+	}
+	if len(read.diagnostics) != 1 || read.diagnostics[0].Severity != SeverityWarning {
+		t.Errorf("got diagnostics %+v, want one warning", read.diagnostics)
+	}
+}
+
+func TestSortDiagnostics(t *testing.T) {
+	diagnostics := []Diagnostic{
+		{File: "a.jar", Stage: StageParse, Severity: SeverityWarning, Error: "b"},
+		{File: "a.jar", Stage: StageParse, Severity: SeverityError, Error: "c"},
+		{File: "a.jar", Stage: StageParse, Severity: SeverityWarning, Error: "a"},
+	}
+	sortDiagnostics(diagnostics)
+	var got []string
+	for _, d := range diagnostics {
+		got = append(got, d.Error)
+	}
+	if want := []string{"c", "a", "b"}; !slices.Equal(got, want) {
+		t.Errorf("sorted errors %v, want %v", got, want)
+	}
+}
+
+func TestUnknownNeoForgeDependencyType(t *testing.T) {
+	jar := zipJar(t, map[string]string{
+		"META-INF/neoforge.mods.toml": `modLoader = "javafml"
+loaderVersion = "[1,)"
+[[mods]]
+modId = "mod"
+version = "1.0"
+[[dependencies.mod]]
+modId = "other"
+type = "sometimes"
+versionRange = "[1,)"
+`,
+	})
+	jars, err := getModJarsAt("mod.jar", bytes.NewReader(jar), int64(len(jar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := jars["mod.jar"]
+	if len(read.metas) != 1 || len(read.metas[0].Depends) != 1 {
+		t.Fatalf("read %+v, want mod with one dependency", read.metas)
+	}
+	if dep := read.metas[0].Depends[0]; dep.ID != "other" || dep.Relation != RelationRequires {
+		t.Errorf("read dependency %+v, want other required", dep)
+	}
+	if len(read.diagnostics) != 1 || read.diagnostics[0].Severity != SeverityWarning {
+		t.Errorf("got diagnostics %+v, want one warning", read.diagnostics)
+	}
+}
+
+func TestResolveAliases(t *testing.T) {
+	provider := func(id string, provides ...string) ModMetadata {
+		return ModMetadata{Mod: Mod{ID: id, Version: "1.0"}, Provides: provides}
+	}
+	tests := []struct {
+		name string
+		mods []ModMetadata
+		want map[string]string
+	}{
+		{"provided ID", []ModMetadata{provider("a", "api")}, map[string]string{"api": "a"}},
+		{"own ID", []ModMetadata{provider("a", "a")}, map[string]string{}},
+		{"installed mod wins", []ModMetadata{provider("a", "api"), provider("api")}, map[string]string{}},
+		{"lowest ID wins", []ModMetadata{provider("c", "api"), provider("b", "api"), provider("d", "api")}, map[string]string{"api": "b"}},
+		{"several IDs", []ModMetadata{provider("a", "x", "y"), provider("b", "y")}, map[string]string{"x": "a", "y": "a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mods := make(map[string]ModMetadata)
+			for _, mod := range tt.mods {
+				mods[mod.ID] = mod
+			}
+			// Map iteration order varies, so each case is resolved a few times
+			for range 10 {
+				if got := resolveAliases(mods); !maps.Equal(got, tt.want) {
+					t.Fatalf("resolveAliases() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPartialModsTomlIsDiagnosed(t *testing.T) {
+	jar := zipJar(t, map[string]string{
+		"META-INF/mods.toml": `modLoader = "javafml"
+loaderVersion = "[47,)"
+[[mods]]
+modId = "a"
+version = "1.0"
+[[mods]]
+version = "1.0"
+`,
+	})
+	jars, err := getModJarsAt("mod.jar", bytes.NewReader(jar), int64(len(jar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := jars["mod.jar"]
+	if len(read.metas) != 1 || read.metas[0].ID != "a" {
+		t.Fatalf("read %+v, want a", read.metas)
+	}
+	if len(read.diagnostics) != 1 || read.diagnostics[0].Severity != SeverityWarning {
+		t.Errorf("got diagnostics %+v, want one warning", read.diagnostics)
+	}
+}
+
+func TestDefaultIconIsAppliedToTheGraph(t *testing.T) {
+	icon := defaultIconData
+	defaultIconData = "data:image/png;base64,default"
+	defer func() {
+		defaultIconData = icon
+	}()
+	jar := zipJar(t, map[string]string{
+		"META-INF/mods.toml": `modLoader = "javafml"
+loaderVersion = "[47,)"
+[[mods]]
+modId = "mod"
+version = "1.0"
+`,
+	})
+	jars, err := getModJarsAt("mod.jar", bytes.NewReader(jar), int64(len(jar)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metas := jars["mod.jar"].metas
+	if len(metas) != 1 || metas[0].IconData != "" {
+		t.Fatalf("read %+v, want mod without an icon of its own", metas)
+	}
+	graph, err := generateDependencyGraph(map[string]ModMetadata{"mod": metas[0]}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node, _ := graph.GetNode("mod"); node.Icon != defaultIconData {
+		t.Errorf("mod has icon %q, want the default icon", node.Icon)
+	}
+}
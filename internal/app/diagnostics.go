@@ -0,0 +1,97 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+)
+
+var errNoMetadata = errors.New("no fabric.mod.json, quilt.mod.json, mods.toml, neoforge.mods.toml or mcmod.info found")
+
+// Severity tells how much a diagnostic affects the graph.
+type Severity string
+
+const (
+	// SeverityError means a file, or the mods in it, are missing from the graph
+	SeverityError Severity = "error"
+	// SeverityWarning means a file was read but may not be what it seems
+	SeverityWarning Severity = "warning"
+	// SeverityInfo means a file was skipped as expected, such as a library
+	// bundled in a mod
+	SeverityInfo Severity = "info"
+)
+
+// Stage is the step of a scan a diagnostic was raised in.
+type Stage string
+
+const (
+	// StageRead is opening a file and reading it as a jar
+	StageRead Stage = "read"
+	// StageNested is extracting a jar bundled in another one
+	StageNested Stage = "nested"
+	// StageMetadata is looking for the metadata files of a jar
+	StageMetadata Stage = "metadata"
+	// StageParse is parsing the JSON or TOML of a metadata file
+	StageParse Stage = "parse"
+)
+
+// Diagnostic is a problem met while scanning a file, which would otherwise
+// only show as the file missing from the graph.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Stage    Stage    `json:"stage"`
+	Severity Severity `json:"severity"`
+	Error    string   `json:"error"`
+}
+
+// diagnose records a diagnostic about a file that could not be read at all.
+func (s *scanner) diagnose(d Diagnostic) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.diagnostics = append(s.diagnostics, d)
+}
+
+// diagnose records a diagnostic about the jar, or a file bundled in it.
+func (jar *modJar) diagnose(file string, stage Stage, severity Severity, err error) {
+	jar.diagnostics = append(jar.diagnostics, Diagnostic{
+		File:     file,
+		Stage:    stage,
+		Severity: severity,
+		Error:    err.Error(),
+	})
+}
+
+// logDiagnostics logs each diagnostic at the level of its severity. Jars
+// without metadata are common among bundled libraries, so those are only
+// logged when verbose.
+func logDiagnostics(diagnostics []Diagnostic) {
+	for _, d := range diagnostics {
+		level := slog.LevelDebug
+		switch d.Severity {
+		case SeverityError:
+			level = slog.LevelError
+		case SeverityWarning:
+			level = slog.LevelWarn
+		}
+		slog.Log(context.Background(), level, "Problem reading file", "file", d.File, "stage", d.Stage, "error", d.Error)
+	}
+}
+
+// sortDiagnostics orders diagnostics by file, stage, severity and error, so
+// that the same scan always reports them in the same order.
+func sortDiagnostics(diagnostics []Diagnostic) {
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		a, b := diagnostics[i], diagnostics[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Stage != b.Stage {
+			return a.Stage < b.Stage
+		}
+		if a.Severity != b.Severity {
+			return a.Severity < b.Severity
+		}
+		return a.Error < b.Error
+	})
+}
@@ -0,0 +1,274 @@
+package app
+
+import (
+	"archive/zip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+// ModFile is a jar read while scanning a modpack. A file declares one or more
+// mods, and may itself be bundled inside another jar.
+type ModFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size,omitempty"`
+	// Hashes are keyed by algorithm, using the names of the Modrinth index
+	Hashes map[string]string `json:"hashes,omitempty"`
+	// NestedIn is the path of the jar this file is bundled in, if any
+	NestedIn string `json:"nestedIn,omitempty"`
+	// Bundles are the paths of the jars bundled in this file
+	Bundles []string `json:"bundles,omitempty"`
+	// Mods are the IDs of the mods the file declares
+	Mods []string `json:"mods,omitempty"`
+}
+
+// Duplicate is a mod installed by several files, usually an old and a new
+// version of it left side by side. All but one of the files should be deleted.
+type Duplicate struct {
+	ID        string        `json:"id"`
+	Instances []ModInstance `json:"instances"`
+}
+
+// ModInstance is one of the files a duplicate mod was read from.
+type ModInstance struct {
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// modJar is a scanned jar along with the mods read from it. The jar itself is
+// closed once its metadata has been read.
+type modJar struct {
+	ModFile
+	metas       []ModMetadata
+	diagnostics []Diagnostic
+}
+
+// nestedJarPath is the path of a jar bundled in another one, in the form Java
+// uses for jar URLs.
+func nestedJarPath(parent, entry string) string {
+	return parent + "!/" + entry
+}
+
+// hashFile describes a file of the given size, hashing its content as it is
+// read from content.
+func hashFile(path string, size int64, content io.Reader) (ModFile, error) {
+	sha1Hash := sha1.New()
+	sha512Hash := sha512.New()
+	if _, err := io.Copy(io.MultiWriter(sha1Hash, sha512Hash), content); err != nil {
+		return ModFile{}, err
+	}
+	return ModFile{
+		Path: path,
+		Size: size,
+		Hashes: map[string]string{
+			"sha1":   hex.EncodeToString(sha1Hash.Sum(nil)),
+			"sha512": hex.EncodeToString(sha512Hash.Sum(nil)),
+		},
+	}, nil
+}
+
+// jarInJarDirs are the folders loaders load bundled jars from. Fabric and
+// Quilt use META-INF/jars, Forge and NeoForge's JarJar use META-INF/jarjar.
+var jarInJarDirs = []string{"META-INF/jars/", "META-INF/jarjar/"}
+
+// nestedJarEntries lists the jars bundled in r: those declared by its Fabric,
+// Quilt or JarJar metadata, along with any other jar stored directly in one of
+// the jar-in-jar folders. Entries are ordered by name.
+func nestedJarEntries(r *zip.Reader) []*zip.File {
+	entries := make(map[string]*zip.File)
+	declared := make(map[string]struct{})
+	for _, f := range r.File {
+		entries[f.Name] = f
+		switch f.Name {
+		case "fabric.mod.json":
+			var data struct {
+				Jars []struct {
+					File string `json:"file"`
+				} `json:"jars"`
+			}
+			if readZipJSON(f, &data) == nil {
+				for _, jar := range data.Jars {
+					declared[jar.File] = struct{}{}
+				}
+			}
+		case "quilt.mod.json":
+			var data struct {
+				QuiltLoader struct {
+					Jars []string `json:"jars"`
+				} `json:"quilt_loader"`
+			}
+			if readZipJSON(f, &data) == nil {
+				for _, jar := range data.QuiltLoader.Jars {
+					declared[jar] = struct{}{}
+				}
+			}
+		case "META-INF/jarjar/metadata.json":
+			var data struct {
+				Jars []struct {
+					Path string `json:"path"`
+				} `json:"jars"`
+			}
+			if readZipJSON(f, &data) == nil {
+				for _, jar := range data.Jars {
+					declared[jar.Path] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, f := range r.File {
+		dir, name := path.Split(f.Name)
+		for _, jarDir := range jarInJarDirs {
+			if dir == jarDir && strings.HasSuffix(name, ".jar") {
+				declared[f.Name] = struct{}{}
+			}
+		}
+	}
+	var nested []*zip.File
+	for name := range declared {
+		if f, ok := entries[strings.TrimPrefix(name, "/")]; ok {
+			nested = append(nested, f)
+		}
+	}
+	sort.Slice(nested, func(i, j int) bool {
+		return nested[i].Name < nested[j].Name
+	})
+	return nested
+}
+
+// openZipEntry gives random access to a file stored in archive, so that a
+// bundled jar can be opened without holding it in memory. Stored entries are
+// read in place, while compressed ones are inflated to a temporary file. The
+// returned function releases the entry.
+func openZipEntry(archive io.ReaderAt, f *zip.File) (io.ReaderAt, func(), error) {
+	if f.Method == zip.Store {
+		offset, err := f.DataOffset()
+		if err != nil {
+			return nil, nil, err
+		}
+		return io.NewSectionReader(archive, offset, int64(f.UncompressedSize64)), func() {}, nil
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		_ = rc.Close()
+	}()
+	tmp, err := os.CreateTemp("", "modpackgraph-*.jar")
+	if err != nil {
+		return nil, nil, err
+	}
+	release := func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}
+	if _, err := io.Copy(tmp, rc); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return tmp, release, nil
+}
+
+func readZipJSON(f *zip.File, v any) error {
+	data, err := readZipFile(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// preferInstance reports whether a mod found in two jars should be read from
+// a rather than b. Jars installed on their own are preferred over bundled
+// ones, then the highest version, usually the one meant to be kept, then jars
+// are ordered by path so that the choice does not depend on the order they
+// were scanned in.
+func preferInstance(a ModMetadata, aJar *modJar, b ModMetadata, bJar *modJar) bool {
+	if bJar == nil {
+		return true
+	}
+	if (aJar.NestedIn == "") != (bJar.NestedIn == "") {
+		return aJar.NestedIn == ""
+	}
+	if aKnown, bKnown := isKnownVersion(a.Version), isKnownVersion(b.Version); aKnown != bKnown {
+		return aKnown
+	} else if aKnown {
+		if cmp := CompareVersions(MavenScheme, a.Version, b.Version); cmp != 0 {
+			return cmp > 0
+		}
+	}
+	return aJar.Path < bJar.Path
+}
+
+// addBundledEdges links every mod declared by a bundled jar to the mods of the
+// jar bundling it.
+func addBundledEdges(graph *Graph, jars []ModFile) {
+	parents := make(map[string]ModFile, len(jars))
+	for _, jar := range jars {
+		parents[jar.Path] = jar
+	}
+	for _, jar := range jars {
+		parent, ok := parents[jar.NestedIn]
+		if jar.NestedIn == "" || !ok {
+			continue
+		}
+		for _, id := range jar.Mods {
+			for _, parentID := range parent.Mods {
+				graph.AddEdgeFromIDs(Edge{
+					Source:   id,
+					Target:   parentID,
+					Label:    path.Base(jar.Path),
+					Relation: RelationBundledBy,
+					Status:   StatusOK,
+				})
+			}
+		}
+	}
+}
+
+// findDuplicates lists the mods declared by more than one jar installed on its
+// own. Jars bundling a copy of a mod are left out, since loaders pick a single
+// version among the bundled ones. Duplicates are ordered by ID and their
+// instances by path.
+func findDuplicates(jars map[string]*modJar, instances map[string][]ModMetadata) []Duplicate {
+	var duplicates []Duplicate
+	for id, metas := range instances {
+		var installed []ModInstance
+		for _, meta := range metas {
+			if jar, ok := jars[meta.Path]; ok && jar.NestedIn == "" {
+				installed = append(installed, ModInstance{Path: meta.Path, Version: meta.Version})
+			}
+		}
+		if len(installed) < 2 {
+			continue
+		}
+		sort.Slice(installed, func(i, j int) bool {
+			return installed[i].Path < installed[j].Path
+		})
+		duplicates = append(duplicates, Duplicate{ID: id, Instances: installed})
+	}
+	sort.Slice(duplicates, func(i, j int) bool {
+		return duplicates[i].ID < duplicates[j].ID
+	})
+	return duplicates
+}
+
+// modFiles lists the files of the given jars, ordered by path.
+func modFiles(jars map[string]*modJar) []ModFile {
+	files := make([]ModFile, 0, len(jars))
+	for _, jar := range jars {
+		file := jar.ModFile
+		sort.Strings(file.Mods)
+		sort.Strings(file.Bundles)
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Path < files[j].Path
+	})
+	return files
+}
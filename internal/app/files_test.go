@@ -0,0 +1,56 @@
+package app
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDuplicateMods(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.jar":   fabricJar(t, `{"id": "a", "version": "1.0", "depends": {"b": ">=2.0"}}`),
+		"b-1.jar": fabricJar(t, `{"id": "b", "version": "1.5"}`),
+		"b-2.jar": fabricJar(t, `{"id": "b", "version": "2.1"}`),
+	})
+	graph, err := (&scanner{}).buildGraph(context.Background(), GraphGenerationOptions{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Duplicate{{ID: "b", Instances: []ModInstance{
+		{Path: filepath.Join(dir, "b-1.jar"), Version: "1.5"},
+		{Path: filepath.Join(dir, "b-2.jar"), Version: "2.1"},
+	}}}
+	if !reflect.DeepEqual(graph.Duplicates, want) {
+		t.Errorf("duplicates %+v, want %+v", graph.Duplicates, want)
+	}
+	// The newest copy is the one the graph is built from
+	node, _ := graph.GetNode("b")
+	if node.PresentVersion != "2.1" || node.Status != StatusDuplicate {
+		t.Errorf("b read as %s with status %s, want 2.1 and duplicate", node.PresentVersion, node.Status)
+	}
+	if edge, _ := graph.GetEdge("a", "b"); edge.Status != StatusOK {
+		t.Errorf("dependency of a on b has status %s, want ok", edge.Status)
+	}
+}
+
+func TestDuplicateModsKeepVersionMismatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.jar":   fabricJar(t, `{"id": "a", "version": "1.0", "depends": {"b": ">=3.0"}}`),
+		"b-1.jar": fabricJar(t, `{"id": "b", "version": "1.5"}`),
+		"b-2.jar": fabricJar(t, `{"id": "b", "version": "2.1"}`),
+	})
+	graph, err := (&scanner{}).buildGraph(context.Background(), GraphGenerationOptions{Path: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, _ := graph.GetNode("b")
+	if node.Status != StatusDuplicate {
+		t.Errorf("b has status %s, want duplicate", node.Status)
+	}
+	if edge, _ := graph.GetEdge("a", "b"); edge.Status != StatusVersionMismatch {
+		t.Errorf("dependency of a on b has status %s, want version_mismatch", edge.Status)
+	}
+}
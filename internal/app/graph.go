@@ -3,36 +3,158 @@ package app
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 )
 
 type GraphGenerationOptions struct {
 	Path string `json:"path,omitempty"`
+	// Target overrides the environment declared by the modpack, if any.
+	Target *TargetEnvironment `json:"target,omitempty"`
 }
 
 type Graph struct {
-	Nodes map[string]*Node `json:"nodes" ts_type:"Node[]"`
-	Edges map[string]*Edge `json:"links" ts_type:"Edge[]"`
+	Nodes              map[string]*Node    `json:"nodes" ts_type:"Node[]"`
+	Edges              map[string]*Edge    `json:"links" ts_type:"Edge[]"`
+	Files              []ModFile           `json:"files,omitempty"`
+	Duplicates         []Duplicate         `json:"duplicates,omitempty"`
+	Pack               *PackInfo           `json:"pack,omitempty"`
+	Target             TargetEnvironment   `json:"target"`
+	PlatformViolations []PlatformViolation `json:"platformViolations,omitempty"`
+	// Diagnostics are the problems met while reading files
+	Diagnostics []Diagnostic `json:"diagnostics,omitempty"`
+	// fileIndex maps the path of each file to its index in Files
+	fileIndex map[string]int
 }
 
 func (g *Graph) MarshalJSON() ([]byte, error) {
 	type Alias struct {
-		Nodes []Node `json:"nodes" ts_type:"Node[]"`
-		Edges []Edge `json:"links" ts_type:"Edge[]"`
+		Nodes              []Node              `json:"nodes" ts_type:"Node[]"`
+		Edges              []Edge              `json:"links" ts_type:"Edge[]"`
+		Files              []ModFile           `json:"files,omitempty"`
+		Duplicates         []Duplicate         `json:"duplicates,omitempty"`
+		Pack               *PackInfo           `json:"pack,omitempty"`
+		Target             TargetEnvironment   `json:"target"`
+		PlatformViolations []PlatformViolation `json:"platformViolations,omitempty"`
+		Diagnostics        []Diagnostic        `json:"diagnostics,omitempty"`
 	}
+	return json.Marshal(&Alias{
+		Nodes:              g.SortedNodes(),
+		Edges:              g.SortedEdges(),
+		Files:              g.Files,
+		Duplicates:         g.Duplicates,
+		Pack:               g.Pack,
+		Target:             g.Target,
+		PlatformViolations: g.PlatformViolations,
+		Diagnostics:        g.Diagnostics,
+	})
+}
+
+// SortedNodes returns a copy of the graph nodes ordered by ID, so that
+// serialized output is stable between runs.
+func (g *Graph) SortedNodes() []Node {
 	nodes := make([]Node, 0, len(g.Nodes))
 	for _, node := range g.Nodes {
 		nodes = append(nodes, *node)
 	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].ID < nodes[j].ID
+	})
+	return nodes
+}
+
+// SortedEdges returns a copy of the graph edges ordered by source, target and
+// relation.
+func (g *Graph) SortedEdges() []Edge {
 	edges := make([]Edge, 0, len(g.Edges))
 	for _, edge := range g.Edges {
 		edges = append(edges, *edge)
 	}
-	return json.Marshal(&Alias{
-		Nodes: nodes,
-		Edges: edges,
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].Source != edges[j].Source {
+			return edges[i].Source < edges[j].Source
+		}
+		if edges[i].Target != edges[j].Target {
+			return edges[i].Target < edges[j].Target
+		}
+		return edges[i].Relation < edges[j].Relation
 	})
+	return edges
+}
+
+// GraphDelta is the difference between two graphs of the same pack. Nodes and
+// edges are listed when they were added or changed, while the other parts of
+// the graph are small enough to be sent whole.
+type GraphDelta struct {
+	Nodes              []Node              `json:"nodes,omitempty"`
+	RemovedNodes       []string            `json:"removedNodes,omitempty"`
+	Edges              []Edge              `json:"links,omitempty" ts_type:"Edge[]"`
+	RemovedEdges       []Edge              `json:"removedLinks,omitempty" ts_type:"Edge[]"`
+	Files              []ModFile           `json:"files"`
+	Duplicates         []Duplicate         `json:"duplicates"`
+	Pack               *PackInfo           `json:"pack"`
+	Target             TargetEnvironment   `json:"target"`
+	PlatformViolations []PlatformViolation `json:"platformViolations"`
+	Diagnostics        []Diagnostic        `json:"diagnostics"`
+}
+
+// Empty reports whether the delta changes nothing.
+func (d GraphDelta) Empty() bool {
+	return len(d.Nodes) == 0 && len(d.RemovedNodes) == 0 &&
+		len(d.Edges) == 0 && len(d.RemovedEdges) == 0
+}
+
+// Diff returns the changes that turn g into next. Nodes and edges are ordered
+// like in SortedNodes and SortedEdges.
+func (g *Graph) Diff(next *Graph) GraphDelta {
+	delta := GraphDelta{
+		Files:              next.Files,
+		Duplicates:         next.Duplicates,
+		Pack:               next.Pack,
+		Target:             next.Target,
+		PlatformViolations: next.PlatformViolations,
+		Diagnostics:        next.Diagnostics,
+	}
+	for _, node := range next.SortedNodes() {
+		if old, ok := g.Nodes[node.ID]; !ok || !reflect.DeepEqual(*old, node) {
+			delta.Nodes = append(delta.Nodes, node)
+		}
+	}
+	for _, node := range g.SortedNodes() {
+		if _, ok := next.Nodes[node.ID]; !ok {
+			delta.RemovedNodes = append(delta.RemovedNodes, node.ID)
+		}
+	}
+	for _, edge := range next.SortedEdges() {
+		if old, ok := g.Edges[edge.key()]; !ok || *old != edge {
+			delta.Edges = append(delta.Edges, edge)
+		}
+	}
+	for _, edge := range g.SortedEdges() {
+		if _, ok := next.Edges[edge.key()]; !ok {
+			delta.RemovedEdges = append(delta.RemovedEdges, edge)
+		}
+	}
+	return delta
 }
 
+// Status describes whether a dependency, or every dependency on a mod, is
+// satisfied by the installed mods.
+type Status string
+
+const (
+	StatusOK              Status = "ok"
+	StatusVersionMismatch Status = "version_mismatch"
+	StatusMissing         Status = "missing"
+	// StatusUnresolved marks mods that are not installed by any jar read, but
+	// may be one of the files a pack manifest lists without bundling them
+	StatusUnresolved Status = "unresolved"
+	// StatusConflict marks installed mods that are declared incompatible
+	StatusConflict Status = "conflict"
+	// StatusDuplicate marks mods installed by several files
+	StatusDuplicate Status = "duplicate"
+)
+
 type Node struct {
 	ID              string `json:"id,omitempty" ts_type:"string | number"`
 	Label           string `json:"name,omitempty"`
@@ -40,13 +162,41 @@ type Node struct {
 	Present         bool   `json:"present,omitempty"`
 	PresentVersion  string `json:"presentVersion,omitempty"`
 	RequiredVersion Compat `json:"requiredVersion,omitempty" ts_type:"string"`
+	Status          Status `json:"status,omitempty"`
+	// Aliases are the other mod IDs this mod provides
+	Aliases []string `json:"aliases,omitempty"`
+	// Indexed nodes are files listed by a modpack manifest without being
+	// bundled, so their mod metadata is unknown.
+	Indexed bool `json:"indexed,omitempty"`
+	// Optional indexed files are only installed when the user opts in, so
+	// they are not present
+	Optional bool `json:"optional,omitempty"`
+	// Path is the file the mod was read from. Mods declared by the same jar
+	// share a path.
+	Path      string            `json:"path,omitempty"`
+	ProjectID string            `json:"projectId,omitempty"`
+	FileID    string            `json:"fileId,omitempty"`
+	Hashes    map[string]string `json:"hashes,omitempty"`
+	Env       *Env              `json:"env,omitempty"`
 }
 
 type Edge struct {
-	Source   string `json:"source"`
-	Target   string `json:"target"`
-	Label    string `json:"label,omitempty"`
-	Required bool   `json:"required,omitempty"`
+	Source   string   `json:"source"`
+	Target   string   `json:"target"`
+	Label    string   `json:"label,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Relation Relation `json:"relation,omitempty"`
+	Status   Status   `json:"status,omitempty"`
+}
+
+// key identifies the edge in the edges of a graph. A mod may both depend on
+// another mod and be incompatible with some of its versions, so
+// incompatibilities are keyed apart from the other relations.
+func (e Edge) key() string {
+	if e.Relation.Incompatible() {
+		return fmt.Sprintf("%s-%s->%s", e.Source, e.Relation, e.Target)
+	}
+	return fmt.Sprintf("%s->%s", e.Source, e.Target)
 }
 
 func NewGraph() *Graph {
@@ -69,27 +219,17 @@ func (g *Graph) AddEdgeFromIDs(edge Edge) {
 		return
 	}
 	// Prevent duplicate edges
-	for _, e := range g.Edges {
-		if e.Source == edge.Source && e.Target == edge.Target {
-			return
-		}
+	if _, exists := g.Edges[edge.key()]; exists {
+		return
 	}
 	// Prevent edges between non-existent nodes
-	sourceExists := false
-	targetExists := false
-	for _, node := range g.Nodes {
-		if node.ID == edge.Source {
-			sourceExists = true
-		}
-		if node.ID == edge.Target {
-			targetExists = true
-		}
-	}
+	_, sourceExists := g.Nodes[edge.Source]
+	_, targetExists := g.Nodes[edge.Target]
 	if !sourceExists || !targetExists {
 		return
 	}
 	// Add the edge
-	g.Edges[fmt.Sprintf("%s->%s", edge.Source, edge.Target)] = &edge
+	g.Edges[edge.key()] = &edge
 }
 
 func (g *Graph) GetNode(id string) (*Node, bool) {
@@ -97,6 +237,48 @@ func (g *Graph) GetNode(id string) (*Node, bool) {
 	return node, exists
 }
 
+// SetFiles replaces the scanned files of the graph and indexes them by path.
+func (g *Graph) SetFiles(files []ModFile) {
+	g.Files = files
+	g.fileIndex = make(map[string]int, len(files))
+	for i, file := range files {
+		g.fileIndex[file.Path] = i
+	}
+}
+
+// GetFile returns the scanned file with the given path.
+func (g *Graph) GetFile(path string) (*ModFile, bool) {
+	if g.fileIndex == nil {
+		g.SetFiles(g.Files)
+	}
+	i, ok := g.fileIndex[path]
+	if !ok {
+		return nil, false
+	}
+	return &g.Files[i], true
+}
+
+// RemovalFile returns the file to delete to remove a mod from the pack, which
+// is the outermost file bundling the jar the mod was read from.
+func (g *Graph) RemovalFile(id string) (*ModFile, bool) {
+	node, ok := g.GetNode(id)
+	if !ok || node.Path == "" {
+		return nil, false
+	}
+	file, ok := g.GetFile(node.Path)
+	if !ok {
+		return nil, false
+	}
+	for file.NestedIn != "" {
+		parent, ok := g.GetFile(file.NestedIn)
+		if !ok {
+			break
+		}
+		file = parent
+	}
+	return file, true
+}
+
 func (g *Graph) GetEdge(sourceID, targetID string) (*Edge, bool) {
 	edge, exists := g.Edges[fmt.Sprintf("%s->%s", sourceID, targetID)]
 	return edge, exists
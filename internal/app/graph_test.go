@@ -0,0 +1,19 @@
+package app
+
+import "testing"
+
+func TestRemovalFile(t *testing.T) {
+	graph := NewGraph()
+	graph.AddNode(Node{ID: "lib", Path: "mods/outer.jar!/META-INF/jars/lib.jar"})
+	graph.SetFiles([]ModFile{
+		{Path: "mods/outer.jar", Bundles: []string{"mods/outer.jar!/META-INF/jars/lib.jar"}},
+		{Path: "mods/outer.jar!/META-INF/jars/lib.jar", NestedIn: "mods/outer.jar"},
+	})
+	file, ok := graph.RemovalFile("lib")
+	if !ok || file.Path != "mods/outer.jar" {
+		t.Errorf("RemovalFile(lib) = %v, %v, want mods/outer.jar", file, ok)
+	}
+	if _, ok := graph.GetFile("mods/missing.jar"); ok {
+		t.Error("GetFile found a file that was never scanned")
+	}
+}
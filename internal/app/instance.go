@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Component is an entry of the component list of a Prism Launcher or MultiMC
+// instance, such as Minecraft itself or a mod loader.
+type Component struct {
+	UID     string `json:"uid"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// mmcPack is the mmc-pack.json file of a Prism Launcher or MultiMC instance
+type mmcPack struct {
+	Components []struct {
+		UID        string `json:"uid"`
+		Version    string `json:"version"`
+		CachedName string `json:"cachedName"`
+	} `json:"components"`
+	FormatVersion int `json:"formatVersion"`
+}
+
+// Loaders known to the launchers, by component UID
+var instanceLoaders = map[string]string{
+	"net.minecraftforge":         "forge",
+	"net.neoforged":              "neoforge",
+	"net.fabricmc.fabric-loader": "fabric",
+	"org.quiltmc.quilt-loader":   "quilt",
+	"com.mumfrey.liteloader":     "liteloader",
+}
+
+// isInstanceDir reports whether dir is the root of a Prism Launcher or MultiMC
+// instance.
+func isInstanceDir(dir string) bool {
+	for _, name := range []string{"mmc-pack.json", "instance.cfg"} {
+		if info, err := os.Stat(filepath.Join(dir, name)); err == nil && !info.IsDir() {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan a Prism Launcher or MultiMC instance. Only the mods folder of the game
+// directory is scanned, and the instance components describe the pack.
+func (s *scanner) scanInstance(ctx context.Context, dir string) (*packContents, error) {
+	modsDir := ""
+	for _, gameDir := range []string{".minecraft", "minecraft"} {
+		candidate := filepath.Join(dir, gameDir, "mods")
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			modsDir = candidate
+			break
+		}
+	}
+	if modsDir == "" {
+		return nil, fmt.Errorf("no mods folder found in instance %s", dir)
+	}
+	contents, err := s.scanModFolder(ctx, modsDir)
+	if err != nil {
+		return nil, err
+	}
+	s.watch(filepath.Join(dir, "mmc-pack.json"), filepath.Join(dir, "instance.cfg"))
+	contents.info, err = readInstanceInfo(dir)
+	if err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+func readInstanceInfo(dir string) (*PackInfo, error) {
+	config := readInstanceConfig(dir)
+	info := &PackInfo{
+		Name:        config["name"],
+		JavaVersion: config["JavaVersion"],
+	}
+	if info.Name == "" {
+		info.Name = filepath.Base(dir)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "mmc-pack.json"))
+	if os.IsNotExist(err) {
+		return info, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var pack mmcPack
+	if err := json.Unmarshal(data, &pack); err != nil {
+		return nil, fmt.Errorf("invalid mmc-pack.json: %w", err)
+	}
+	for _, c := range pack.Components {
+		info.Components = append(info.Components, Component{
+			UID:     c.UID,
+			Name:    c.CachedName,
+			Version: c.Version,
+		})
+		if c.UID == "net.minecraft" {
+			info.MinecraftVersion = c.Version
+		} else if loader, ok := instanceLoaders[c.UID]; ok && info.Loader == "" {
+			info.Loader = loader
+			info.LoaderVersion = c.Version
+		}
+	}
+	return info, nil
+}
+
+// readInstanceConfig reads the settings of instance.cfg, such as the instance
+// name and the version of the Java it runs on. A missing file has no settings.
+func readInstanceConfig(dir string) map[string]string {
+	config := make(map[string]string)
+	f, err := os.Open(filepath.Join(dir, "instance.cfg"))
+	if err != nil {
+		return config
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), "=")
+		if ok {
+			config[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return config
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// mrpackIndex is the modrinth.index.json file at the root of a .mrpack
+type mrpackIndex struct {
+	FormatVersion int    `json:"formatVersion"`
+	Game          string `json:"game"`
+	VersionID     string `json:"versionId"`
+	Name          string `json:"name"`
+	Files         []struct {
+		Path      string            `json:"path"`
+		Hashes    map[string]string `json:"hashes"`
+		Env       *Env              `json:"env"`
+		Downloads []string          `json:"downloads"`
+		FileSize  int64             `json:"fileSize"`
+	} `json:"files"`
+	Dependencies map[string]string `json:"dependencies"`
+}
+
+// Folders whose content is copied over the instance when installing a pack
+var mrpackOverrideDirs = []string{"overrides/", "client-overrides/", "server-overrides/"}
+
+// Scan a Modrinth modpack. Jars bundled in the override folders are read like
+// the ones in a mods folder, while files that are only listed in the index
+// become indexed nodes.
+func (s *scanner) scanMrpack(ctx context.Context, packPath string) (*packContents, error) {
+	s.watch(packPath)
+	r, err := openPackArchive(packPath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	var index *mrpackIndex
+	for _, f := range r.File {
+		if f.Name != "modrinth.index.json" {
+			continue
+		}
+		data, err := readZipFile(f)
+		if err != nil {
+			return nil, err
+		}
+		index = &mrpackIndex{}
+		if err := json.Unmarshal(data, index); err != nil {
+			return nil, fmt.Errorf("invalid modrinth.index.json: %w", err)
+		}
+		break
+	}
+	if index == nil {
+		return nil, fmt.Errorf("modrinth.index.json not found in %s", packPath)
+	}
+	jars, bundled, err := s.readOverrideJars(ctx, r, mrpackOverrideDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []PackFile
+	for _, f := range index.Files {
+		if _, ok := bundled[f.Path]; ok {
+			continue
+		}
+		files = append(files, PackFile{
+			Path:      f.Path,
+			ProjectID: modrinthProjectID(f.Downloads),
+			Hashes:    f.Hashes,
+			Env:       f.Env,
+			Downloads: f.Downloads,
+			Size:      f.FileSize,
+			Required:  true,
+		})
+	}
+	info := &PackInfo{
+		Name:             index.Name,
+		Version:          index.VersionID,
+		MinecraftVersion: index.Dependencies["minecraft"],
+	}
+	for _, loader := range []string{"neoforge", "forge", "fabric-loader", "quilt-loader"} {
+		if version, ok := index.Dependencies[loader]; ok {
+			info.Loader = strings.TrimSuffix(loader, "-loader")
+			info.LoaderVersion = version
+			break
+		}
+	}
+	contents := readJars(jars)
+	contents.files = files
+	contents.info = info
+	return contents, nil
+}
+
+// modrinthProjectID extracts the project ID from a Modrinth CDN download URL
+// of the form https://cdn.modrinth.com/data/<project>/versions/<version>/<file>
+func modrinthProjectID(downloads []string) string {
+	for _, d := range downloads {
+		u, err := url.Parse(d)
+		if err != nil || u.Host != "cdn.modrinth.com" {
+			continue
+		}
+		parts := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+		if len(parts) >= 2 && parts[0] == "data" {
+			return parts[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// packwizPack is the pack.toml file at the root of a packwiz pack
+type packwizPack struct {
+	Name    string `toml:"name"`
+	Version string `toml:"version"`
+	Index   struct {
+		File string `toml:"file"`
+	} `toml:"index"`
+	Versions map[string]string `toml:"versions"`
+}
+
+// packwizIndex is the index file referenced by pack.toml
+type packwizIndex struct {
+	Files []struct {
+		File     string `toml:"file"`
+		Metafile bool   `toml:"metafile"`
+	} `toml:"files"`
+}
+
+// packwizMetafile is a .pw.toml file describing a mod to download
+type packwizMetafile struct {
+	Name     string `toml:"name"`
+	Filename string `toml:"filename"`
+	Side     string `toml:"side"`
+	Download struct {
+		URL        string `toml:"url"`
+		HashFormat string `toml:"hash-format"`
+		Hash       string `toml:"hash"`
+	} `toml:"download"`
+	Update struct {
+		Modrinth *struct {
+			ModID   string `toml:"mod-id"`
+			Version string `toml:"version"`
+		} `toml:"modrinth"`
+		CurseForge *struct {
+			ProjectID int `toml:"project-id"`
+			FileID    int `toml:"file-id"`
+		} `toml:"curseforge"`
+	} `toml:"update"`
+}
+
+// Loaders that can appear in the versions table of pack.toml
+var packwizLoaders = []string{"neoforge", "forge", "fabric", "quilt", "liteloader"}
+
+func isPackwizDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "pack.toml"))
+	return err == nil && !info.IsDir()
+}
+
+func readToml(file string, v any) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	if err := toml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid %s: %w", filepath.Base(file), err)
+	}
+	return nil
+}
+
+// Scan a packwiz pack. Jars checked into the pack are read like the ones in a
+// mods folder, while mods described by metafiles become indexed nodes unless
+// their jar is present as well.
+func (s *scanner) scanPackwiz(ctx context.Context, dir string) (*packContents, error) {
+	s.watch(dir)
+	var pack packwizPack
+	if err := readToml(filepath.Join(dir, "pack.toml"), &pack); err != nil {
+		return nil, err
+	}
+	indexFile := pack.Index.File
+	if indexFile == "" {
+		indexFile = "index.toml"
+	}
+	indexPath := filepath.Join(dir, filepath.FromSlash(indexFile))
+	var index packwizIndex
+	if err := readToml(indexPath, &index); err != nil {
+		return nil, err
+	}
+	// Paths in the index are relative to the index file
+	indexDir := filepath.Dir(indexPath)
+
+	var files []PackFile
+	for _, f := range index.Files {
+		if !f.Metafile {
+			continue
+		}
+		var meta packwizMetafile
+		if err := readToml(filepath.Join(indexDir, filepath.FromSlash(f.File)), &meta); err != nil {
+			s.diagnose(Diagnostic{
+				File:     f.File,
+				Stage:    StageParse,
+				Severity: SeverityError,
+				Error:    err.Error(),
+			})
+			continue
+		}
+		installPath := path.Join(path.Dir(f.File), meta.Filename)
+		if _, err := os.Stat(filepath.Join(indexDir, filepath.FromSlash(installPath))); err == nil {
+			// The jar itself is part of the pack and is scanned below
+			continue
+		}
+		file := PackFile{
+			Path:     installPath,
+			Name:     meta.Name,
+			Env:      packwizEnv(meta.Side),
+			Required: true,
+		}
+		if meta.Download.URL != "" {
+			file.Downloads = []string{meta.Download.URL}
+		}
+		if meta.Download.Hash != "" {
+			file.Hashes = map[string]string{meta.Download.HashFormat: meta.Download.Hash}
+		}
+		if mr := meta.Update.Modrinth; mr != nil {
+			file.ProjectID = mr.ModID
+			file.FileID = mr.Version
+		} else if cf := meta.Update.CurseForge; cf != nil {
+			file.ProjectID = strconv.Itoa(cf.ProjectID)
+			file.FileID = strconv.Itoa(cf.FileID)
+		}
+		files = append(files, file)
+	}
+
+	jars, err := s.findFolderJars(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	info := &PackInfo{
+		Name:             pack.Name,
+		Version:          pack.Version,
+		MinecraftVersion: pack.Versions["minecraft"],
+	}
+	for _, loader := range packwizLoaders {
+		if version, ok := pack.Versions[loader]; ok {
+			info.Loader = loader
+			info.LoaderVersion = version
+			break
+		}
+	}
+	contents := readJars(jars)
+	contents.files = files
+	contents.info = info
+	return contents, nil
+}
+
+// packwizEnv converts a packwiz side into the Modrinth env representation.
+func packwizEnv(side string) *Env {
+	switch side {
+	case "client":
+		return &Env{Client: "required", Server: "unsupported"}
+	case "server":
+		return &Env{Client: "unsupported", Server: "required"}
+	default:
+		return &Env{Client: "required", Server: "required"}
+	}
+}
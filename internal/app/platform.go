@@ -0,0 +1,181 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TargetEnvironment is the game, loader and Java setup a modpack runs on. Empty
+// fields are unknown and are not checked.
+type TargetEnvironment struct {
+	MinecraftVersion string `json:"minecraftVersion,omitempty"`
+	// Loader is one of "forge", "neoforge", "fabric" or "quilt"
+	Loader        string `json:"loader,omitempty"`
+	LoaderVersion string `json:"loaderVersion,omitempty"`
+	JavaVersion   string `json:"javaVersion,omitempty"`
+}
+
+// PlatformViolation is a dependency of a mod on the platform that the target
+// environment does not satisfy.
+type PlatformViolation struct {
+	Mod      string   `json:"mod"`
+	Platform string   `json:"platform"`
+	Relation Relation `json:"relation"`
+	// Type is the relation under the name it had before relations were added,
+	// kept for consumers of the JSON output
+	Type     string `json:"type"`
+	Required Compat `json:"required" ts_type:"string"`
+	Actual   string `json:"actual"`
+	Message  string `json:"message"`
+}
+
+// Dependency IDs that refer to the platform rather than to a mod, mapped to
+// the platform they constrain
+var platformIDs = map[string]string{
+	"minecraft":     "minecraft",
+	"java":          "java",
+	"forge":         "forge",
+	"neoforge":      "neoforge",
+	"fabricloader":  "fabric",
+	"fabric-loader": "fabric",
+	"quilt_loader":  "quilt",
+}
+
+// resolveTarget fills the fields of target that were left empty from what the
+// modpack itself declares.
+func resolveTarget(target *TargetEnvironment, info *PackInfo) TargetEnvironment {
+	var result TargetEnvironment
+	if target != nil {
+		result = *target
+	}
+	if info == nil {
+		return result
+	}
+	if result.MinecraftVersion == "" {
+		result.MinecraftVersion = info.MinecraftVersion
+	}
+	if result.JavaVersion == "" {
+		result.JavaVersion = info.JavaVersion
+	}
+	if result.Loader == "" {
+		result.Loader = info.Loader
+		if result.LoaderVersion == "" {
+			result.LoaderVersion = info.LoaderVersion
+		}
+	}
+	return result
+}
+
+// loaderSatisfies reports whether running on loader satisfies a dependency on
+// required. NeoForge for 1.20.1 still identified itself as Forge and Quilt
+// can load Fabric mods, but the versions of the emulated loaders cannot be
+// checked.
+func loaderSatisfies(loader, required string) (ok bool, checkVersion bool) {
+	switch {
+	case loader == required:
+		return true, true
+	case loader == "neoforge" && required == "forge":
+		return true, false
+	case loader == "quilt" && required == "fabric":
+		return true, false
+	}
+	return false, false
+}
+
+// javaVersion normalizes legacy Java version names, so that "1.8" reads "8".
+func javaVersion(v string) string {
+	return strings.TrimPrefix(v, "1.")
+}
+
+// javaCompat normalizes the bounds of a Java requirement the way javaVersion
+// normalizes the installed version, so that ">=1.8" accepts Java 17.
+func javaCompat(c Compat) Compat {
+	if len(c.intervals) == 0 {
+		return c
+	}
+	intervals := make([]versionInterval, len(c.intervals))
+	for i, iv := range c.intervals {
+		iv.minVersion = javaVersion(iv.minVersion)
+		iv.maxVersion = javaVersion(iv.maxVersion)
+		intervals[i] = iv
+	}
+	return newCompat(c.scheme, intervals...)
+}
+
+// checkPlatform evaluates the platform dependencies of every mod against the
+// target environment.
+func checkPlatform(mods map[string]ModMetadata, target TargetEnvironment) []PlatformViolation {
+	var violations []PlatformViolation
+	for _, mod := range mods {
+		for _, dep := range mod.Platform {
+			platform := platformIDs[strings.ToLower(dep.ID)]
+			relation := dep.relation()
+			if relation != RelationRequires && !relation.Incompatible() {
+				continue
+			}
+			violation := PlatformViolation{
+				Mod:      mod.ID,
+				Platform: platform,
+				Relation: relation,
+				Required: dep.Compatibility,
+			}
+			var actual string
+			compat := dep.Compatibility
+			checkVersion := true
+			switch platform {
+			case "minecraft":
+				actual = target.MinecraftVersion
+			case "java":
+				actual = target.JavaVersion
+				if actual != "" {
+					actual = javaVersion(actual)
+				}
+				compat = javaCompat(compat)
+			default:
+				if target.Loader == "" {
+					continue
+				}
+				var ok bool
+				ok, checkVersion = loaderSatisfies(target.Loader, platform)
+				if !ok {
+					if relation == RelationRequires {
+						violation.Actual = target.Loader
+						violation.Message = fmt.Sprintf("%s requires %s, but the pack uses %s", mod.ID, platform, target.Loader)
+						violations = append(violations, violation)
+					}
+					continue
+				}
+				actual = target.LoaderVersion
+			}
+			if actual == "" || !checkVersion {
+				continue
+			}
+			violation.Actual = actual
+			contained := compat.Contains(actual)
+			if relation == RelationRequires && !contained {
+				violation.Message = fmt.Sprintf("%s requires %s %s, but the pack uses %s", mod.ID, platform, dep.Compatibility.String(), actual)
+				violations = append(violations, violation)
+			} else if relation.Incompatible() && contained {
+				violation.Message = fmt.Sprintf("%s is incompatible with %s %s", mod.ID, platform, actual)
+				violations = append(violations, violation)
+			}
+		}
+	}
+	// Dependencies are read from maps, so violations are fully ordered to keep
+	// the output the same between runs
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.Mod != b.Mod {
+			return a.Mod < b.Mod
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		if a.Relation != b.Relation {
+			return a.Relation < b.Relation
+		}
+		return a.Message < b.Message
+	})
+	return violations
+}
@@ -0,0 +1,70 @@
+package app
+
+import "testing"
+
+func TestCheckPlatformJavaVersion(t *testing.T) {
+	tests := []struct {
+		requirement string
+		fabric      bool
+		installed   string
+		violated    bool
+	}{
+		{">=1.8", true, "17", false},
+		{">=1.8", true, "1.8", false},
+		{">=17", true, "1.8", true},
+		{"[1.8,)", false, "17", false},
+		{"[1.8,)", false, "1.8.0_292", false},
+		{"[17,)", false, "11", true},
+		{"[1.8,11)", false, "17", true},
+	}
+	for _, tt := range tests {
+		var compat Compat
+		var err error
+		if tt.fabric {
+			compat, err = parseFabricPredicate(tt.requirement)
+		} else {
+			compat, err = parseMavenRange(tt.requirement)
+		}
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tt.requirement, err)
+		}
+		mods := map[string]ModMetadata{
+			"mod": {
+				Mod:      Mod{ID: "mod"},
+				Platform: []Dep{{ID: "java", Required: true, Compatibility: compat}},
+			},
+		}
+		violations := checkPlatform(mods, TargetEnvironment{JavaVersion: tt.installed})
+		if got := len(violations) > 0; got != tt.violated {
+			t.Errorf("java %s on %s: violated = %v, want %v", tt.requirement, tt.installed, got, tt.violated)
+		}
+	}
+}
+
+func TestCheckPlatformOrder(t *testing.T) {
+	requires, err := parseFabricPredicate(">=1.21")
+	if err != nil {
+		t.Fatal(err)
+	}
+	breaks, err := parseFabricPredicate("1.20.x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	deps := []Dep{
+		{ID: "minecraft", Required: true, Compatibility: requires},
+		{ID: "minecraft", Relation: RelationBreaks, Compatibility: breaks},
+	}
+	var want []PlatformViolation
+	for _, order := range [][]Dep{deps, {deps[1], deps[0]}} {
+		mods := map[string]ModMetadata{"mod": {Mod: Mod{ID: "mod"}, Platform: order}}
+		violations := checkPlatform(mods, TargetEnvironment{MinecraftVersion: "1.20.1"})
+		if len(violations) != 2 {
+			t.Fatalf("got violations %+v, want two", violations)
+		}
+		if want == nil {
+			want = violations
+		} else if violations[0].Relation != want[0].Relation || violations[1].Relation != want[1].Relation {
+			t.Errorf("violations ordered %s, %s then %s, %s", want[0].Relation, want[1].Relation, violations[0].Relation, violations[1].Relation)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// scanWorkers bounds how many jars are read at the same time.
+var scanWorkers = runtime.GOMAXPROCS(0)
+
+// ScanProgress tells how far a scan is. Jars are discovered as the pack is
+// read, so Discovered may still grow while they are processed.
+type ScanProgress struct {
+	Discovered int `json:"discovered"`
+	Processed  int `json:"processed"`
+	// Current is the name of the jar that was last started
+	Current string `json:"current,omitempty"`
+}
+
+// scanner holds what the stages of a scan share.
+type scanner struct {
+	cache *scanCache
+	// roots are the files and folders the last scan read from
+	roots []string
+	// stamps are the files under roots as they were before the scan read
+	// them, so that changes made during the scan are noticed afterwards
+	stamps map[string]fileStamp
+	// onProgress is called whenever the progress changes, if set, possibly
+	// from several workers at once
+	onProgress func(ScanProgress)
+	mu         sync.Mutex
+	progress   ScanProgress
+	// diagnostics are about files no jar could be read from
+	diagnostics []Diagnostic
+}
+
+func newScanner() *scanner {
+	return &scanner{cache: loadScanCache()}
+}
+
+// watch records files or folders a scan depends on, so that a watcher knows
+// what to monitor for changes. It must be called before they are read.
+func (s *scanner) watch(paths ...string) {
+	s.roots = append(s.roots, paths...)
+	if s.stamps == nil {
+		s.stamps = make(map[string]fileStamp)
+	}
+	maps.Copy(s.stamps, snapshotRoots(paths))
+}
+
+// report updates the progress of the scan and passes it on. onProgress is
+// called without holding the lock, so that a slow listener never holds up the
+// workers.
+func (s *scanner) report(update func(*ScanProgress)) {
+	s.mu.Lock()
+	update(&s.progress)
+	progress := s.progress
+	s.mu.Unlock()
+	if s.onProgress != nil {
+		s.onProgress(progress)
+	}
+}
+
+// openJars reads the jars described by sources on a bounded pool of workers,
+// reporting each of them to the scanner progress under the base of the given
+// name. Jars that cannot be read are diagnosed under the full name. Each worker
+// only touches the jars it read, and extractMods walks the result ordered by
+// path, so the outcome does not depend on scheduling. Jars that were not
+// started yet are skipped once ctx is cancelled, and its error is returned.
+func openJars[S any](ctx context.Context, s *scanner, sources []S, name func(S) string, open func(S) (map[string]*modJar, error)) (map[string]*modJar, error) {
+	s.report(func(p *ScanProgress) {
+		p.Discovered += len(sources)
+	})
+	results := make([]map[string]*modJar, len(sources))
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(scanWorkers, len(sources)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				s.report(func(p *ScanProgress) {
+					p.Current = filepath.Base(name(sources[i]))
+				})
+				slog.Debug("Reading jar", "file", name(sources[i]))
+				jars, err := open(sources[i])
+				if err != nil {
+					s.diagnose(Diagnostic{
+						File:     name(sources[i]),
+						Stage:    StageRead,
+						Severity: SeverityError,
+						Error:    err.Error(),
+					})
+				}
+				results[i] = jars
+				s.report(func(p *ScanProgress) {
+					p.Processed++
+				})
+			}
+		}()
+	}
+feed:
+	for i := range sources {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	jars := make(map[string]*modJar)
+	for _, result := range results {
+		for p, jar := range result {
+			jars[p] = jar
+		}
+	}
+	return jars, nil
+}
@@ -0,0 +1,58 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestScanOutputIgnoresScheduling(t *testing.T) {
+	workers := scanWorkers
+	scanWorkers = 8
+	defer func() {
+		scanWorkers = workers
+	}()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"broken.jar":   "not a jar",
+		"library.jar":  string(zipJar(t, map[string]string{"README": "no metadata"})),
+		"shared-1.jar": fabricJar(t, `{"id": "shared", "version": "1.0"}`),
+		"shared-2.jar": fabricJar(t, `{"id": "shared", "version": "1.0"}`),
+		"bundler.jar": string(zipJar(t, map[string]string{
+			"fabric.mod.json":             `{"id": "bundler", "version": "1.0", "provides": ["api"]}`,
+			"META-INF/jars/bundled.jar":   fabricJar(t, `{"id": "bundled", "version": "1.0"}`),
+			"META-INF/jars/shared.jar":    fabricJar(t, `{"id": "shared", "version": "0.9"}`),
+			"META-INF/jars/nometa.jar":    string(zipJar(t, map[string]string{"README": "library"})),
+			"META-INF/jars/corrupted.jar": "not a jar",
+		})),
+	}
+	for i := range 20 {
+		files[fmt.Sprintf("mod-%02d.jar", i)] = fabricJar(t, fmt.Sprintf(
+			`{"id": "mod%d", "version": "1.%d", "depends": {"api": "*", "mod%d": ">=1.0", "missing%d": "*"}}`,
+			i, i, (i+1)%20, i%3))
+	}
+	writeFiles(t, dir, files)
+
+	var want []byte
+	for range 10 {
+		// Every run starts from an empty scan cache, so that every jar is
+		// read by whichever worker picks it up
+		t.Setenv("XDG_CACHE_HOME", t.TempDir())
+		graph, err := BuildGraph(context.Background(), GraphGenerationOptions{Path: dir}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.Marshal(graph)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == nil {
+			want = got
+		} else if !bytes.Equal(got, want) {
+			t.Fatalf("scan output changed between runs:\n%s\n%s", want, got)
+		}
+	}
+}
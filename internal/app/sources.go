@@ -0,0 +1,229 @@
+package app
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Env describes on which sides a file is needed, using the Modrinth values
+// "required", "optional" and "unsupported".
+type Env struct {
+	Client string `json:"client,omitempty"`
+	Server string `json:"server,omitempty"`
+}
+
+// PackFile is a file listed by a modpack manifest. Unless it is also bundled
+// with the pack, only what the manifest declares about it is known.
+type PackFile struct {
+	Path      string            `json:"path,omitempty"`
+	Name      string            `json:"name,omitempty"`
+	ProjectID string            `json:"projectId,omitempty"`
+	FileID    string            `json:"fileId,omitempty"`
+	Hashes    map[string]string `json:"hashes,omitempty"`
+	Env       *Env              `json:"env,omitempty"`
+	Downloads []string          `json:"downloads,omitempty"`
+	Size      int64             `json:"size,omitempty"`
+	// Required is unset for files the pack only installs when the user opts
+	// in, such as optional CurseForge manifest entries
+	Required bool `json:"required"`
+}
+
+// nodeID identifies the file in the graph. Mod IDs never contain a '/' or a
+// ':', so neither form can collide with a mod node.
+func (f PackFile) nodeID() string {
+	if f.Path != "" {
+		return f.Path
+	}
+	return fmt.Sprintf("curseforge:%s:%s", f.ProjectID, f.FileID)
+}
+
+// PackInfo describes the modpack a graph was generated from, when it was read
+// from a pack format that declares it.
+type PackInfo struct {
+	Name             string `json:"name,omitempty"`
+	Version          string `json:"version,omitempty"`
+	MinecraftVersion string `json:"minecraftVersion,omitempty"`
+	Loader           string `json:"loader,omitempty"`
+	LoaderVersion    string `json:"loaderVersion,omitempty"`
+	// JavaVersion is the Java version a launcher instance runs on, if known
+	JavaVersion string `json:"javaVersion,omitempty"`
+	// Components of the launcher instance the pack was read from, if any
+	Components []Component `json:"components,omitempty"`
+}
+
+// packContents is what a modpack source holds, before it is turned into a
+// graph.
+type packContents struct {
+	mods        map[string]ModMetadata
+	jars        []ModFile
+	duplicates  []Duplicate
+	diagnostics []Diagnostic
+	files       []PackFile
+	info        *PackInfo
+}
+
+// BuildGraph generates the dependency graph described by options. It does not
+// need a running Wails application, so it is also used by the headless CLI.
+// The scan stops early with the context's error when ctx is cancelled, and
+// reports its progress to onProgress unless it is nil.
+func BuildGraph(ctx context.Context, options GraphGenerationOptions, onProgress func(ScanProgress)) (*Graph, error) {
+	s := newScanner()
+	s.onProgress = onProgress
+	graph, err := s.buildGraph(ctx, options)
+	if err := s.cache.save(); err != nil {
+		slog.Warn("Failed to save scan cache", "error", err)
+	}
+	return graph, err
+}
+
+func (s *scanner) buildGraph(ctx context.Context, options GraphGenerationOptions) (*Graph, error) {
+	start := time.Now()
+	s.diagnostics = nil
+	slog.Info("Scanning pack", "path", options.Path)
+	contents, err := s.scanSource(ctx, options.Path)
+	if err != nil {
+		if ctx.Err() != nil {
+			slog.Info("Scan cancelled", "path", options.Path)
+		} else {
+			slog.Error("Failed to scan pack", "path", options.Path, "error", err)
+		}
+		return nil, err
+	}
+	graph, err := generateDependencyGraph(contents.mods, contents.jars, contents.files)
+	if err != nil {
+		slog.Error("Failed to generate dependency graph", "path", options.Path, "error", err)
+		return nil, err
+	}
+	graph.SetFiles(contents.jars)
+	graph.Duplicates = contents.duplicates
+	// A duplicate is flagged whatever else is wrong with the mod, as deleting
+	// the other files may be what fixes it. A version mismatch is still shown
+	// on the edges to it.
+	for _, duplicate := range contents.duplicates {
+		if node, ok := graph.GetNode(duplicate.ID); ok {
+			node.Status = StatusDuplicate
+		}
+	}
+	graph.Diagnostics = slices.Concat(s.diagnostics, contents.diagnostics)
+	sortDiagnostics(graph.Diagnostics)
+	graph.Pack = contents.info
+	target := resolveTarget(options.Target, contents.info)
+	graph.Target = target
+	graph.PlatformViolations = checkPlatform(contents.mods, target)
+	logDiagnostics(graph.Diagnostics)
+	slog.Info("Scanned pack",
+		"path", options.Path,
+		"files", len(graph.Files),
+		"mods", len(graph.Nodes),
+		"edges", len(graph.Edges),
+		"duplicates", len(graph.Duplicates),
+		"platformViolations", len(graph.PlatformViolations),
+		"diagnostics", len(graph.Diagnostics),
+		"duration", time.Since(start))
+	return graph, nil
+}
+
+// scanSource reads a modpack from any of the supported source formats.
+func (s *scanner) scanSource(ctx context.Context, sourcePath string) (*packContents, error) {
+	switch strings.ToLower(filepath.Ext(sourcePath)) {
+	case ".mrpack":
+		slog.Debug("Reading Modrinth pack", "path", sourcePath)
+		return s.scanMrpack(ctx, sourcePath)
+	case ".zip":
+		slog.Debug("Reading CurseForge pack", "path", sourcePath)
+		return s.scanCurseForgePack(ctx, sourcePath)
+	}
+	if isInstanceDir(sourcePath) {
+		slog.Debug("Reading launcher instance", "path", sourcePath)
+		return s.scanInstance(ctx, sourcePath)
+	}
+	if isPackwizDir(sourcePath) {
+		slog.Debug("Reading packwiz pack", "path", sourcePath)
+		return s.scanPackwiz(ctx, sourcePath)
+	}
+	slog.Debug("Reading mods folder", "path", sourcePath)
+	return s.scanModFolder(ctx, sourcePath)
+}
+
+// packArchive is a pack zip kept open for random access, so that the jars it
+// stores can be read in place.
+type packArchive struct {
+	*zip.Reader
+	path string
+	file *os.File
+}
+
+func openPackArchive(packPath string) (*packArchive, error) {
+	f, err := os.Open(packPath)
+	if err != nil {
+		return nil, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r, err := zip.NewReader(f, stat.Size())
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return &packArchive{Reader: r, path: packPath, file: f}, nil
+}
+
+func (a *packArchive) Close() error {
+	return a.file.Close()
+}
+
+// readOverrideJars reads every jar stored under one of the given folders of a
+// pack archive. It also returns the set of instance paths the jars are
+// installed to, which is their path with the override folder stripped.
+func (s *scanner) readOverrideJars(ctx context.Context, r *packArchive, dirs []string) (map[string]*modJar, map[string]struct{}, error) {
+	var files []*zip.File
+	installPaths := make(map[string]string)
+	for _, f := range r.File {
+		if !strings.HasSuffix(f.Name, ".jar") {
+			continue
+		}
+		for _, dir := range dirs {
+			if strings.HasPrefix(f.Name, dir) {
+				files = append(files, f)
+				installPaths[f.Name] = strings.TrimPrefix(f.Name, dir)
+				break
+			}
+		}
+	}
+	jars, err := openJars(ctx, s, files, func(f *zip.File) string {
+		return f.Name
+	}, func(f *zip.File) (map[string]*modJar, error) {
+		return s.cache.getZipEntryJars(r.path, r.file, f)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	installed := make(map[string]struct{})
+	for name, installPath := range installPaths {
+		if _, ok := jars[name]; ok {
+			installed[installPath] = struct{}{}
+		}
+	}
+	return jars, installed, nil
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(rc)
+	_ = rc.Close()
+	return data, err
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeFiles creates the given files under dir, along with their folders.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// fabricJar builds the jar of a Fabric mod with the given fabric.mod.json.
+func fabricJar(t *testing.T, modJSON string) string {
+	t.Helper()
+	return string(zipJar(t, map[string]string{"fabric.mod.json": modJSON}))
+}
+
+func TestScanMrpack(t *testing.T) {
+	dir := t.TempDir()
+	packPath := filepath.Join(dir, "pack.mrpack")
+	pack := zipJar(t, map[string]string{
+		"modrinth.index.json": `{
+			"formatVersion": 1,
+			"game": "minecraft",
+			"versionId": "1.2.0",
+			"name": "Test Pack",
+			"files": [
+				{"path": "mods/bundled.jar", "hashes": {}, "downloads": []},
+				{
+					"path": "mods/indexed.jar",
+					"hashes": {"sha1": "abc"},
+					"downloads": ["https://cdn.modrinth.com/data/AABBCCDD/versions/1/indexed.jar"],
+					"fileSize": 10
+				}
+			],
+			"dependencies": {"minecraft": "1.20.1", "fabric-loader": "0.15.0"}
+		}`,
+		"overrides/mods/bundled.jar": fabricJar(t, `{"id": "bundled", "version": "1.0"}`),
+	})
+	if err := os.WriteFile(packPath, pack, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := (&scanner{}).scanMrpack(context.Background(), packPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := contents.mods["bundled"]; !ok || len(contents.mods) != 1 {
+		t.Errorf("read mods %v, want bundled", contents.mods)
+	}
+	// The bundled jar is listed in the index as well, but only once in the
+	// graph
+	if len(contents.files) != 1 || contents.files[0].Path != "mods/indexed.jar" || contents.files[0].ProjectID != "AABBCCDD" {
+		t.Errorf("read indexed files %+v, want mods/indexed.jar of AABBCCDD", contents.files)
+	}
+	want := PackInfo{Name: "Test Pack", Version: "1.2.0", MinecraftVersion: "1.20.1", Loader: "fabric", LoaderVersion: "0.15.0"}
+	if !reflect.DeepEqual(contents.info, &want) {
+		t.Errorf("read pack info %+v, want %+v", contents.info, want)
+	}
+}
+
+func TestScanCurseForgePack(t *testing.T) {
+	dir := t.TempDir()
+	packPath := filepath.Join(dir, "pack.zip")
+	pack := zipJar(t, map[string]string{
+		"manifest.json": `{
+			"minecraft": {
+				"version": "1.20.1",
+				"modLoaders": [{"id": "forge-47.2.0", "primary": true}]
+			},
+			"manifestType": "minecraftModpack",
+			"name": "Test Pack",
+			"version": "1.0",
+			"files": [
+				{"projectID": 1, "fileID": 10, "required": true},
+				{"projectID": 2, "fileID": 20, "required": false}
+			],
+			"overrides": "extra"
+		}`,
+		"extra/mods/bundled.jar":     fabricJar(t, `{"id": "bundled", "version": "1.0"}`),
+		"overrides/mods/ignored.jar": fabricJar(t, `{"id": "ignored", "version": "1.0"}`),
+	})
+	if err := os.WriteFile(packPath, pack, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := (&scanner{}).scanCurseForgePack(context.Background(), packPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the overrides folder named by the manifest is read
+	if _, ok := contents.mods["bundled"]; !ok || len(contents.mods) != 1 {
+		t.Errorf("read mods %v, want bundled", contents.mods)
+	}
+	wantFiles := []PackFile{
+		{ProjectID: "1", FileID: "10", Required: true},
+		{ProjectID: "2", FileID: "20", Required: false},
+	}
+	if !reflect.DeepEqual(contents.files, wantFiles) {
+		t.Errorf("read indexed files %+v, want %+v", contents.files, wantFiles)
+	}
+	want := PackInfo{Name: "Test Pack", Version: "1.0", MinecraftVersion: "1.20.1", Loader: "forge", LoaderVersion: "47.2.0"}
+	if !reflect.DeepEqual(contents.info, &want) {
+		t.Errorf("read pack info %+v, want %+v", contents.info, want)
+	}
+}
+
+func TestScanInstance(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"instance.cfg": "[General]\nJavaVersion=17.0.8\nname=Test Instance\n",
+		"mmc-pack.json": `{
+			"components": [
+				{"uid": "net.minecraft", "version": "1.20.1", "cachedName": "Minecraft"},
+				{"uid": "net.fabricmc.fabric-loader", "version": "0.15.0", "cachedName": "Fabric Loader"}
+			],
+			"formatVersion": 1
+		}`,
+		".minecraft/mods/mod.jar": fabricJar(t, `{"id": "mod", "version": "1.0"}`),
+	})
+	if !isInstanceDir(dir) {
+		t.Fatal("instance not detected")
+	}
+
+	contents, err := (&scanner{}).scanInstance(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := contents.mods["mod"]; !ok || len(contents.mods) != 1 {
+		t.Errorf("read mods %v, want mod", contents.mods)
+	}
+	want := PackInfo{
+		Name:             "Test Instance",
+		MinecraftVersion: "1.20.1",
+		JavaVersion:      "17.0.8",
+		Loader:           "fabric",
+		LoaderVersion:    "0.15.0",
+		Components: []Component{
+			{UID: "net.minecraft", Name: "Minecraft", Version: "1.20.1"},
+			{UID: "net.fabricmc.fabric-loader", Name: "Fabric Loader", Version: "0.15.0"},
+		},
+	}
+	if !reflect.DeepEqual(contents.info, &want) {
+		t.Errorf("read pack info %+v, want %+v", contents.info, want)
+	}
+}
+
+func TestScanPackwiz(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"pack.toml": `name = "Test Pack"
+version = "1.0"
+[index]
+file = "index.toml"
+[versions]
+minecraft = "1.20.1"
+quilt = "0.23.0"
+`,
+		"index.toml": `[[files]]
+file = "mods/indexed.pw.toml"
+metafile = true
+[[files]]
+file = "mods/present.pw.toml"
+metafile = true
+[[files]]
+file = "mods/broken.pw.toml"
+metafile = true
+`,
+		"mods/indexed.pw.toml": `name = "Indexed"
+filename = "indexed.jar"
+side = "client"
+[download]
+url = "https://cdn.modrinth.com/data/AABBCCDD/versions/1/indexed.jar"
+hash-format = "sha1"
+hash = "abc"
+[update.modrinth]
+mod-id = "AABBCCDD"
+version = "EEFF"
+`,
+		"mods/present.pw.toml": `name = "Present"
+filename = "present.jar"
+side = "both"
+`,
+		"mods/present.jar":    fabricJar(t, `{"id": "present", "version": "1.0"}`),
+		"mods/broken.pw.toml": `name = "Broken`,
+	})
+
+	s := &scanner{}
+	contents, err := s.scanPackwiz(context.Background(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := contents.mods["present"]; !ok || len(contents.mods) != 1 {
+		t.Errorf("read mods %v, want present", contents.mods)
+	}
+	// The metafile of a jar checked into the pack is not indexed again
+	wantFiles := []PackFile{{
+		Path:      "mods/indexed.jar",
+		Name:      "Indexed",
+		ProjectID: "AABBCCDD",
+		FileID:    "EEFF",
+		Hashes:    map[string]string{"sha1": "abc"},
+		Env:       &Env{Client: "required", Server: "unsupported"},
+		Downloads: []string{"https://cdn.modrinth.com/data/AABBCCDD/versions/1/indexed.jar"},
+		Required:  true,
+	}}
+	if !reflect.DeepEqual(contents.files, wantFiles) {
+		t.Errorf("read indexed files %+v, want %+v", contents.files, wantFiles)
+	}
+	// A metafile that cannot be read is reported, without failing the scan
+	if len(s.diagnostics) != 1 || s.diagnostics[0].File != "mods/broken.pw.toml" || s.diagnostics[0].Severity != SeverityError {
+		t.Errorf("got diagnostics %+v, want an error on mods/broken.pw.toml", s.diagnostics)
+	}
+	want := PackInfo{Name: "Test Pack", Version: "1.0", MinecraftVersion: "1.20.1", Loader: "quilt", LoaderVersion: "0.23.0"}
+	if !reflect.DeepEqual(contents.info, &want) {
+		t.Errorf("read pack info %+v, want %+v", contents.info, want)
+	}
+}
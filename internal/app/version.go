@@ -0,0 +1,350 @@
+package app
+
+import (
+	"strings"
+)
+
+// VersionScheme selects the rules used to order two version strings.
+type VersionScheme int
+
+const (
+	// MavenScheme orders versions like Maven's ComparableVersion, which is what
+	// Forge and NeoForge use for mods.toml version ranges.
+	MavenScheme VersionScheme = iota
+	// SemVerScheme orders versions like Fabric's SemanticVersion, which is also
+	// used by Quilt.
+	SemVerScheme
+)
+
+// CompareVersions returns -1, 0 or 1 depending on whether a is lower than,
+// equal to or greater than b under the given scheme.
+func CompareVersions(scheme VersionScheme, a, b string) int {
+	if scheme == SemVerScheme {
+		return compareSemVer(a, b)
+	}
+	return compareMaven(a, b)
+}
+
+type semVer struct {
+	components []string
+	prerelease []string
+}
+
+// parseSemVer parses a Fabric style semantic version. Any number of numeric
+// core components is accepted and build metadata is discarded.
+func parseSemVer(v string) (semVer, bool) {
+	if i := strings.IndexByte(v, '+'); i >= 0 {
+		v = v[:i]
+	}
+	var result semVer
+	if i := strings.IndexByte(v, '-'); i >= 0 {
+		result.prerelease = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+	if v == "" {
+		return semVer{}, false
+	}
+	for _, c := range strings.Split(v, ".") {
+		if !isNumeric(c) {
+			return semVer{}, false
+		}
+		result.components = append(result.components, c)
+	}
+	return result, true
+}
+
+// compareSemVer compares two semantic versions. Versions that are not valid
+// semantic versions fall back to Maven ordering, which is the most lenient of
+// the supported schemes.
+func compareSemVer(a, b string) int {
+	va, okA := parseSemVer(a)
+	vb, okB := parseSemVer(b)
+	if !okA || !okB {
+		return compareMaven(a, b)
+	}
+	for i := 0; i < max(len(va.components), len(vb.components)); i++ {
+		ca, cb := "0", "0"
+		if i < len(va.components) {
+			ca = va.components[i]
+		}
+		if i < len(vb.components) {
+			cb = vb.components[i]
+		}
+		if c := compareNumeric(ca, cb); c != 0 {
+			return c
+		}
+	}
+	// A pre-release is lower than the corresponding release
+	switch {
+	case len(va.prerelease) == 0 && len(vb.prerelease) == 0:
+		return 0
+	case len(va.prerelease) == 0:
+		return 1
+	case len(vb.prerelease) == 0:
+		return -1
+	}
+	for i := 0; i < min(len(va.prerelease), len(vb.prerelease)); i++ {
+		pa, pb := va.prerelease[i], vb.prerelease[i]
+		numA, numB := isNumeric(pa), isNumeric(pb)
+		var c int
+		switch {
+		case numA && numB:
+			c = compareNumeric(pa, pb)
+		case numA:
+			c = -1
+		case numB:
+			c = 1
+		default:
+			c = strings.Compare(pa, pb)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(va.prerelease), len(vb.prerelease))
+}
+
+func isNumeric(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// compareNumeric compares two non-negative decimal integers of any length.
+func compareNumeric(a, b string) int {
+	a = strings.TrimLeft(a, "0")
+	b = strings.TrimLeft(b, "0")
+	if c := compareInts(len(a), len(b)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// mavenItem is one element of a parsed Maven version. Exactly one of the
+// three kinds is set: a number, a qualifier or a nested list.
+type mavenItem struct {
+	kind   mavenItemKind
+	number string
+	str    string
+	list   []*mavenItem
+}
+
+type mavenItemKind int
+
+const (
+	mavenInt mavenItemKind = iota
+	mavenString
+	mavenList
+)
+
+var mavenQualifiers = []string{"alpha", "beta", "milestone", "rc", "snapshot", "", "sp"}
+
+var mavenAliases = map[string]string{
+	"ga":      "",
+	"final":   "",
+	"release": "",
+	"cr":      "rc",
+}
+
+// mavenReleaseIndex is the comparable form of the empty (release) qualifier.
+const mavenReleaseIndex = "5"
+
+func newMavenString(s string, followedByDigit bool) *mavenItem {
+	if followedByDigit && len(s) == 1 {
+		switch s[0] {
+		case 'a':
+			s = "alpha"
+		case 'b':
+			s = "beta"
+		case 'm':
+			s = "milestone"
+		}
+	}
+	if alias, ok := mavenAliases[s]; ok {
+		s = alias
+	}
+	return &mavenItem{kind: mavenString, str: s}
+}
+
+func newMavenItem(isDigit bool, s string) *mavenItem {
+	if isDigit {
+		return &mavenItem{kind: mavenInt, number: strings.TrimLeft(s, "0")}
+	}
+	return newMavenString(s, false)
+}
+
+// comparableQualifier orders known qualifiers by their position in
+// mavenQualifiers and places unknown ones after them, sorted lexically.
+func comparableQualifier(q string) string {
+	for i, known := range mavenQualifiers {
+		if q == known {
+			return string(rune('0' + i))
+		}
+	}
+	return string(rune('0'+len(mavenQualifiers))) + "-" + q
+}
+
+func (m *mavenItem) isNull() bool {
+	switch m.kind {
+	case mavenInt:
+		return m.number == ""
+	case mavenString:
+		return comparableQualifier(m.str) == mavenReleaseIndex
+	default:
+		return len(m.list) == 0
+	}
+}
+
+// normalize removes trailing null items, such as the zeros in "1.0.0".
+func (m *mavenItem) normalize() {
+	for i := len(m.list) - 1; i >= 0; i-- {
+		last := m.list[i]
+		if last.isNull() {
+			m.list = append(m.list[:i], m.list[i+1:]...)
+		} else if last.kind != mavenList {
+			break
+		}
+	}
+}
+
+// parseMaven is a port of the parser in Maven's ComparableVersion.
+func parseMaven(version string) *mavenItem {
+	version = strings.ToLower(version)
+	root := &mavenItem{kind: mavenList}
+	list := root
+	stack := []*mavenItem{list}
+	push := func() {
+		next := &mavenItem{kind: mavenList}
+		list.list = append(list.list, next)
+		list = next
+		stack = append(stack, list)
+	}
+	isDigit := false
+	start := 0
+	for i := 0; i < len(version); i++ {
+		c := version[i]
+		switch {
+		case c == '.' || c == '-':
+			if i == start {
+				list.list = append(list.list, &mavenItem{kind: mavenInt})
+			} else {
+				list.list = append(list.list, newMavenItem(isDigit, version[start:i]))
+			}
+			start = i + 1
+			if c == '-' {
+				push()
+			}
+		case c >= '0' && c <= '9':
+			if !isDigit && i > start {
+				// 1.0.0.X1 < 1.0.0-X2, so a qualifier is treated as if preceded by '-'
+				if len(list.list) > 0 {
+					push()
+				}
+				list.list = append(list.list, newMavenString(version[start:i], true))
+				start = i
+				push()
+			}
+			isDigit = true
+		default:
+			if isDigit && i > start {
+				list.list = append(list.list, newMavenItem(true, version[start:i]))
+				start = i
+				push()
+			}
+			isDigit = false
+		}
+	}
+	if len(version) > start {
+		if !isDigit && len(list.list) > 0 {
+			push()
+		}
+		list.list = append(list.list, newMavenItem(isDigit, version[start:]))
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		stack[i].normalize()
+	}
+	return root
+}
+
+// compare orders m against other, where a nil other stands for a missing
+// item at the end of the shorter version.
+func (m *mavenItem) compare(other *mavenItem) int {
+	switch m.kind {
+	case mavenInt:
+		if other == nil {
+			return compareInts(len(m.number), 0)
+		}
+		switch other.kind {
+		case mavenInt:
+			return compareNumeric(m.number, other.number)
+		default:
+			// 1.1 > 1-sp and 1.1 > 1-1
+			return 1
+		}
+	case mavenString:
+		if other == nil {
+			return strings.Compare(comparableQualifier(m.str), mavenReleaseIndex)
+		}
+		switch other.kind {
+		case mavenString:
+			return strings.Compare(comparableQualifier(m.str), comparableQualifier(other.str))
+		default:
+			// 1.any < 1.1 and 1-any < 1-1
+			return -1
+		}
+	default:
+		if other == nil {
+			if len(m.list) == 0 {
+				return 0
+			}
+			return m.list[0].compare(nil)
+		}
+		switch other.kind {
+		case mavenInt:
+			return -1
+		case mavenString:
+			return 1
+		}
+		for i := 0; i < max(len(m.list), len(other.list)); i++ {
+			var l, r *mavenItem
+			if i < len(m.list) {
+				l = m.list[i]
+			}
+			if i < len(other.list) {
+				r = other.list[i]
+			}
+			var c int
+			if l == nil {
+				if r != nil {
+					c = -r.compare(nil)
+				}
+			} else {
+				c = l.compare(r)
+			}
+			if c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+}
+
+func compareMaven(a, b string) int {
+	return parseMaven(a).compare(parseMaven(b))
+}
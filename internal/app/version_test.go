@@ -0,0 +1,88 @@
+package app
+
+import "testing"
+
+func TestCompareMaven(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.0", "1.0.0", 0},
+		{"1", "1.0.0", 0},
+		{"1.0-alpha", "1.0-beta", -1},
+		{"1.0-beta", "1.0-milestone", -1},
+		{"1.0-milestone", "1.0-rc", -1},
+		{"1.0-rc", "1.0-snapshot", -1},
+		{"1.0-snapshot", "1.0", -1},
+		{"1.0", "1.0-sp", -1},
+		{"1.0-sp", "1.0.1", -1},
+		{"1.0-a1", "1.0-alpha-1", 0},
+		{"1.0-b1", "1.0-beta-1", 0},
+		{"1.0-m1", "1.0-milestone-1", 0},
+		{"1.0-cr1", "1.0-rc1", 0},
+		{"1.0-ga", "1.0", 0},
+		{"1.0-final", "1.0", 0},
+		{"1.0-release", "1.0", 0},
+		{"1.0-RC1", "1.0-rc1", 0},
+		{"1.0-unknown", "1.0-sp", 1},
+		{"1.0-abc", "1.0-abd", -1},
+		{"1-1", "1.1", -1},
+		{"1.0.0.x1", "1.0.0-x2", -1},
+		{"1.0-1", "1.0.1", -1},
+		{"1.0-alpha", "1.0", -1},
+		{"47.1.0", "47.0.35", 1},
+		{"1.20.1-0.5.0", "1.20.1-0.4.9", 1},
+		{"2.0", "10.0", -1},
+		{"007", "7", 0},
+	}
+	for _, tt := range tests {
+		if got := compareMaven(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareMaven(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareMaven(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareMaven(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareSemVer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.10.0", "1.9.0", 1},
+		{"1.0", "1.0.0", 0},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-alpha.beta", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0+build.1", "1.0.0+build.2", 0},
+		{"0.15.11", "0.14.21", 1},
+		{"1.2.3.4", "1.2.3", 1},
+		{"1.20.1-forge", "1.20.1-fabric", 1},
+		// Versions that are not semantic versions fall back to Maven ordering,
+		// where a1 is alpha 1
+		{"1.0.0a1", "1.0.0", -1},
+	}
+	for _, tt := range tests {
+		if got := compareSemVer(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareSemVer(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := compareSemVer(tt.b, tt.a); got != -tt.want {
+			t.Errorf("compareSemVer(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsScheme(t *testing.T) {
+	// Maven treats "1.0-rc1" as lower than "1.0", like SemVer pre-releases
+	if got := CompareVersions(MavenScheme, "1.0-rc1", "1.0"); got != -1 {
+		t.Errorf("CompareVersions(MavenScheme, 1.0-rc1, 1.0) = %d, want -1", got)
+	}
+	if got := CompareVersions(SemVerScheme, "1.0.0-rc.1", "1.0.0"); got != -1 {
+		t.Errorf("CompareVersions(SemVerScheme, 1.0.0-rc.1, 1.0.0) = %d, want -1", got)
+	}
+}
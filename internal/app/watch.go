@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// watchInterval is how often a watched pack is checked for changes.
+const watchInterval = 2 * time.Second
+
+// fileStamp identifies the state of a file without reading it.
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// packWatcher rescans a pack whenever a file it was read from changes. It
+// keeps its scanner, and with it the scan cache, between rescans, so that
+// only the jars that changed are read again and the graph is rebuilt from
+// what was read before for the others. Only the changes to the graph are
+// reported.
+type packWatcher struct {
+	options GraphGenerationOptions
+	scanner *scanner
+	graph   *Graph
+	// ctx is cancelled when the watcher is closed, aborting a rescan in
+	// progress
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// newPackWatcher scans the pack described by options and returns a watcher
+// for it along with its graph. The watcher does nothing until it is run.
+// Progress of the initial scan is reported to onProgress unless it is nil.
+func newPackWatcher(ctx context.Context, options GraphGenerationOptions, onProgress func(ScanProgress)) (*packWatcher, *Graph, error) {
+	s := newScanner()
+	if s.cache == nil {
+		// Without a scan cache on disk, what was read from each jar is still
+		// kept for the rescans
+		s.cache = newScanCache("")
+	}
+	s.onProgress = onProgress
+	graph, err := s.buildGraph(ctx, options)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.cache.save(); err != nil {
+		slog.Warn("Failed to save scan cache", "error", err)
+	}
+	// Rescans happen in the background, so they report no progress
+	s.onProgress = nil
+	watchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	return &packWatcher{
+		options: options,
+		scanner: s,
+		graph:   graph,
+		ctx:     watchCtx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}, graph, nil
+}
+
+// run checks the pack for changes until the watcher is stopped. Each rescan
+// that changes the graph is reported to onDelta, and each failed rescan to
+// onError.
+func (w *packWatcher) run(onDelta func(GraphDelta), onError func(error)) {
+	defer close(w.done)
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		w.check(onDelta, onError)
+	}
+}
+
+// check rescans the pack if any file it was read from changed since the last
+// scan.
+func (w *packWatcher) check(onDelta func(GraphDelta), onError func(error)) {
+	next := snapshotRoots(w.scanner.roots)
+	if maps.Equal(next, w.scanner.stamps) {
+		return
+	}
+	slog.Info("Pack changed, rescanning", "path", w.options.Path)
+	// The rescan records its roots again, as it may read from other places,
+	// such as a mods folder that did not exist before
+	roots := w.scanner.roots
+	w.scanner.roots, w.scanner.stamps = nil, nil
+	graph, err := w.scanner.buildGraph(w.ctx, w.options)
+	if w.ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		// Retry once the pack changes again
+		w.scanner.roots, w.scanner.stamps = roots, next
+		onError(err)
+		return
+	}
+	if err := w.scanner.cache.save(); err != nil {
+		slog.Warn("Failed to save scan cache", "error", err)
+	}
+	delta := w.graph.Diff(graph)
+	// A jar can change without any mod changing, such as when it is rebuilt
+	// or when it fails to be read, which is still worth reporting for its
+	// hashes and diagnostics
+	changed := !delta.Empty() || !reflect.DeepEqual(w.graph.Files, graph.Files) ||
+		!reflect.DeepEqual(w.graph.Diagnostics, graph.Diagnostics)
+	w.graph = graph
+	slog.Debug("Rescanned pack", "path", w.options.Path, "changed", changed,
+		"nodes", len(delta.Nodes), "removedNodes", len(delta.RemovedNodes),
+		"edges", len(delta.Edges), "removedEdges", len(delta.RemovedEdges))
+	if changed {
+		onDelta(delta)
+	}
+}
+
+// close stops the watcher, aborting a rescan in progress, and waits for it
+// to return.
+func (w *packWatcher) close() {
+	w.cancel()
+	<-w.done
+}
+
+// vcsDirs are version control folders, such as the one of a packwiz pack
+// checked out from git, which change without the pack changing.
+var vcsDirs = map[string]struct{}{".git": {}, ".hg": {}, ".svn": {}}
+
+// snapshotRoots stamps every file under the given roots. Roots that do not
+// exist have no files, so that creating them counts as a change.
+func snapshotRoots(roots []string) map[string]fileStamp {
+	snapshot := make(map[string]fileStamp)
+	for _, root := range roots {
+		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			if d.IsDir() {
+				if _, ok := vcsDirs[d.Name()]; ok && path != root {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			snapshot[path] = fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()}
+			return nil
+		})
+	}
+	return snapshot
+}
@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatchStampsBeforeScanning(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := &scanner{}
+	s.watch(dir)
+
+	// Version control files are not part of the pack
+	if err := os.WriteFile(filepath.Join(dir, ".git", "index"), []byte("index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(snapshotRoots(s.roots), s.stamps) {
+		t.Error("a change under .git counts as a change to the pack")
+	}
+
+	// A jar added after the folder was recorded, such as while it was being
+	// scanned, is a change
+	if err := os.WriteFile(filepath.Join(dir, "mod.jar"), []byte("jar"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if maps.Equal(snapshotRoots(s.roots), s.stamps) {
+		t.Error("a jar added after the folder was recorded went unnoticed")
+	}
+}
+
+func TestWatchRescanDelta(t *testing.T) {
+	// Without a cache directory, the watcher keeps what it read in memory
+	t.Setenv("XDG_CACHE_HOME", "")
+	t.Setenv("HOME", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.jar": fabricJar(t, `{"id": "a", "version": "1.0", "depends": {"b": ">=1.0"}}`),
+	})
+	w, graph, err := newPackWatcher(context.Background(), GraphGenerationOptions{Path: dir}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.cancel()
+	if edge, ok := graph.GetEdge("a", "b"); !ok || edge.Status != StatusMissing {
+		t.Fatalf("got edge a->b %+v, want it missing", edge)
+	}
+	if _, ok := w.scanner.cache.entries[filepath.Join(dir, "a.jar")]; !ok {
+		t.Fatal("a.jar is not kept for the rescans")
+	}
+
+	writeFiles(t, dir, map[string]string{
+		"b.jar": fabricJar(t, `{"id": "b", "version": "1.2"}`),
+	})
+	var deltas []GraphDelta
+	w.check(func(delta GraphDelta) { deltas = append(deltas, delta) }, func(err error) { t.Fatal(err) })
+	if len(deltas) != 1 {
+		t.Fatalf("got %d deltas, want one", len(deltas))
+	}
+	delta := deltas[0]
+	var added bool
+	for _, node := range delta.Nodes {
+		added = added || node.ID == "b" && node.Present
+	}
+	if !added {
+		t.Errorf("delta nodes %+v do not add b", delta.Nodes)
+	}
+	var resolved bool
+	for _, edge := range delta.Edges {
+		resolved = resolved || edge.Source == "a" && edge.Target == "b" && edge.Status == StatusOK
+	}
+	if !resolved {
+		t.Errorf("delta edges %+v do not resolve a->b", delta.Edges)
+	}
+	if len(delta.RemovedNodes) != 0 || len(delta.RemovedEdges) != 0 {
+		t.Errorf("delta removes nodes %v and edges %+v, want none", delta.RemovedNodes, delta.RemovedEdges)
+	}
+
+	// Nothing changed since, so nothing is rescanned
+	w.check(func(delta GraphDelta) { t.Errorf("got delta %+v without a change", delta) }, func(err error) { t.Fatal(err) })
+}
@@ -0,0 +1,101 @@
+// Package logging sets up the structured logger shared by the desktop
+// application and the command line. Records go to a rotating log file under
+// the user config dir, so that a bad graph can be investigated after the fact,
+// and the most important ones to the console as well.
+package logging
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// Options tells where and how much to log.
+type Options struct {
+	// Name is the base name of the log file, without extension
+	Name string
+	// Verbose logs debug records, and logs to the console as much as to the
+	// log file
+	Verbose bool
+	// Console receives warnings and errors, or every record when verbose. It
+	// is usually stderr, and may be nil.
+	Console io.Writer
+}
+
+// Dir is the folder log files are written to.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "ModpackGraph", "logs"), nil
+}
+
+// Setup installs the default slog logger described by options and returns a
+// function closing its log file. When the log file cannot be opened, records
+// only go to the console and the error is returned along with the logger.
+func Setup(options Options) (func() error, error) {
+	fileLevel := slog.LevelInfo
+	consoleLevel := slog.LevelWarn
+	if options.Verbose {
+		fileLevel = slog.LevelDebug
+		consoleLevel = slog.LevelDebug
+	}
+	var handlers multiHandler
+	if options.Console != nil {
+		handlers = append(handlers, slog.NewTextHandler(options.Console, &slog.HandlerOptions{Level: consoleLevel}))
+	}
+	closeFile := func() error { return nil }
+	dir, err := Dir()
+	if err == nil {
+		var file *rotatingFile
+		file, err = openRotatingFile(filepath.Join(dir, options.Name+".log"), maxLogSize, maxLogBackups)
+		if err == nil {
+			handlers = append(handlers, slog.NewJSONHandler(file, &slog.HandlerOptions{Level: fileLevel}))
+			closeFile = file.Close
+		}
+	}
+	slog.SetDefault(slog.New(handlers))
+	return closeFile, err
+}
+
+// multiHandler passes records on to every handler that accepts their level.
+type multiHandler []slog.Handler
+
+func (h multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, handler := range h {
+		if handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, handler := range h {
+		if handler.Enabled(ctx, r.Level) {
+			errs = append(errs, handler.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	next := make(multiHandler, len(h))
+	for i, handler := range h {
+		next[i] = handler.WithAttrs(attrs)
+	}
+	return next
+}
+
+func (h multiHandler) WithGroup(name string) slog.Handler {
+	next := make(multiHandler, len(h))
+	for i, handler := range h {
+		next[i] = handler.WithGroup(name)
+	}
+	return next
+}
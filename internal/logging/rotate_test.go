@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := openRotatingFile(path, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	for _, record := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each record overflows the file, so the oldest one is dropped past two
+	// backups
+	want := map[string]string{path: "fourth\n", path + ".1": "third\n", path + ".2": "second\n"}
+	for name, content := range want {
+		if got, err := os.ReadFile(name); err != nil || string(got) != content {
+			t.Errorf("read %q from %s (%v), want %q", got, filepath.Base(name), err, content)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("got a third backup (%v), want two", err)
+	}
+}
+
+func TestRotatingFileFailedRename(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.log")
+	// A folder that is not empty cannot be replaced by the log file
+	if err := os.MkdirAll(filepath.Join(path+".1", "taken"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openRotatingFile(path, 8, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	for _, record := range []string{"first\n", "second\n"} {
+		if _, err := f.Write([]byte(record)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The file is opened again, so records keep being written
+	if got, err := os.ReadFile(path); err != nil || string(got) != "first\nsecond\n" {
+		t.Errorf("read %q (%v), want both records", got, err)
+	}
+}
@@ -2,11 +2,16 @@ package main
 
 import (
 	app2 "ModpackGraph/internal/app"
+	"ModpackGraph/internal/logging"
 	"bytes"
 	"embed"
 	"encoding/json"
+	"flag"
+	"io"
 	"io/fs"
+	"log/slog"
 	"net/http"
+	"os"
 	"path"
 	"text/template"
 
@@ -40,21 +45,49 @@ func NewAssetFS(root string) fs.FS {
 	}
 }
 
-func init() {
-	println("hello world")
-}
-
 func (c assetFS) Open(name string) (fs.File, error) {
 	return assets.Open(path.Join(c.root, name))
 }
 
 func main() {
+	verbose := parseFlags(os.Args[1:])
+	closeLog, err := logging.Setup(logging.Options{Name: "app", Verbose: verbose, Console: os.Stderr})
+	if err != nil {
+		slog.Warn("Failed to open log file, logging to the console only", "error", err)
+	}
+	code := run()
+	_ = closeLog()
+	os.Exit(code)
+}
+
+// parseFlags reads the flags of the application, skipping every argument it
+// does not know instead of exiting, as launchers may pass their own, such as
+// the -psn_ argument macOS gives app bundles.
+func parseFlags(args []string) (verbose bool) {
+	flags := flag.NewFlagSet("ModpackGraph", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&verbose, "verbose", false, "log debug records, to the console as well as the log file")
+	for len(args) > 0 {
+		err := flags.Parse(args)
+		rest := flags.Args()
+		if err == nil && len(rest) > 0 || len(rest) == len(args) {
+			// Parsing stops at the first positional argument, and fails on
+			// malformed flags such as ---x without consuming them
+			rest = rest[1:]
+		}
+		args = rest
+	}
+	return verbose
+}
 
+func run() int {
 	var config app2.Config
 	err := json.Unmarshal(configJSON, &config)
 	if err != nil {
-		//log.Fatalf("Failed to parse wails.json: %v", err)
+		slog.Error("Failed to parse wails.json", "error", err)
+		return 1
 	}
+	slog.Info("Starting", "version", config.Info.Version)
 
 	app := app2.NewApp(config)
 
@@ -68,9 +101,10 @@ func main() {
 		AssetServer: &assetserver.Options{
 			Assets: nil,
 			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				slog.Debug("Serving asset", "path", r.URL.Path)
 				if r.URL.Path == "/" {
 					language := r.Header.Get("Accept-Language")
-					//log.Debugf("Detected language: %s", language)
+					slog.Debug("Detected language", "acceptLanguage", language)
 					selectedLang := DefaultUserLang
 					for _, lang := range supportedLangs {
 						if len(language) >= 2 && language[0:2] == lang {
@@ -78,10 +112,10 @@ func main() {
 							break
 						}
 					}
-					//log.Debugf("Selected language: %s", selectedLang)
+					slog.Debug("Selected language", "lang", selectedLang)
 					tmpl, err := template.New("language-index").Parse(languageIndexTMPL)
 					if err != nil {
-						//log.Error("Failed to parse language index template: ", err)
+						slog.Error("Failed to parse language index template", "error", err)
 						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 						return
 					}
@@ -93,7 +127,7 @@ func main() {
 					var buf bytes.Buffer
 					err = tmpl.Execute(&buf, data)
 					if err != nil {
-						//log.Error("Failed to execute language index template: ", err)
+						slog.Error("Failed to execute language index template", "error", err)
 						http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 						return
 					}
@@ -119,6 +153,8 @@ func main() {
 	})
 
 	if err != nil {
-		//log.Fatalf("Error: %v", err.Error())
+		slog.Error("Failed to run application", "error", err)
+		return 1
 	}
+	return 0
 }